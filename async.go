@@ -0,0 +1,254 @@
+package logx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrWriterClosed is returned when writing to an AsyncWriter that has been closed
+var ErrWriterClosed = errors.New("logx: writer closed")
+
+// LevelWriter is implemented by writers that want to know the level of each entry
+// Logger calls WriteLevel instead of Write when its writer implements it
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level Level, p []byte) (n int, err error)
+}
+
+// OverflowPolicy decides what an AsyncWriter does when its queue is full
+type OverflowPolicy int
+
+const (
+	Block          OverflowPolicy = iota // Wait until the background writer frees space
+	DropNewest                           // Discard the entry being written
+	DropOldest                           // Discard the oldest queued entry to make room
+	DropBelowLevel                       // Discard entries below AsyncOptions.DropLevel, wait for the others
+)
+
+// AsyncOptions configures an AsyncWriter
+// Zero values select the defaults noted on each field
+type AsyncOptions struct {
+	QueueSize int             // Maximum number of queued entries, default 1024
+	Policy    OverflowPolicy  // What to do when the queue is full, default Block
+	DropLevel Level           // With DropBelowLevel, entries below this level are dropped when the queue is full
+	OnError   func(err error) // Called with errors from the underlying writer, which have no caller to return them to
+}
+
+// asyncEntry is a queued write
+type asyncEntry struct {
+	level   Level
+	leveled bool // Whether level is known, plain Write calls are never dropped by level
+	data    []byte
+}
+
+// AsyncWriter queues writes in a bounded buffer and writes them to the
+// underlying writer from a background goroutine, so slow writers do not stall callers
+// It implements LevelWriter so DropBelowLevel can see the level of Logger entries
+type AsyncWriter struct {
+	dropped uint64 // Number of dropped entries, accessed atomically, first for 64-bit alignment on 32-bit platforms
+
+	w    io.Writer    // Underlying writer
+	opts AsyncOptions // Queue options
+
+	mu     sync.Mutex
+	cond   *sync.Cond    // Broadcast on every change of queue, busy or closed
+	ring   []asyncEntry  // Queued entries, a ring buffer of QueueSize
+	head   int           // Index of the oldest queued entry
+	count  int           // Number of queued entries
+	busy   bool          // Whether the background goroutine is writing a batch
+	closed bool          // Whether Close has been called
+	done   chan struct{} // Closed when the background goroutine has exited
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to w and starts its background goroutine
+// Close must be called to write out the queued entries and stop the goroutine
+func NewAsyncWriter(w io.Writer, opts AsyncOptions) *AsyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	a := &AsyncWriter{
+		w:    w,
+		opts: opts,
+		ring: make([]asyncEntry, opts.QueueSize),
+		done: make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// Write queues a copy of p
+// Entries written without a level are never dropped by DropBelowLevel
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	return a.enqueue(asyncEntry{data: p})
+}
+
+// WriteLevel queues a copy of p written at the given level
+func (a *AsyncWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return a.enqueue(asyncEntry{level: level, leveled: true, data: p})
+}
+
+// Dropped returns the number of entries discarded because the queue was full
+func (a *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Flush waits until every entry queued so far has been written to the underlying writer
+// It returns ctx.Err() if ctx is done first
+func (a *AsyncWriter) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	go func() {
+		a.mu.Lock()
+		for a.count > 0 || a.busy {
+			a.cond.Wait()
+		}
+		a.mu.Unlock()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+		return flushUnderlying(a.w)
+	case <-ctx.Done():
+		// The helper goroutine exits once the queue drains
+		return ctx.Err()
+	}
+}
+
+// Sync writes out all queued entries and syncs the underlying writer if it supports it
+func (a *AsyncWriter) Sync() error {
+	if err := a.Flush(context.Background()); err != nil {
+		return err
+	}
+	return syncUnderlying(a.w)
+}
+
+// Close stops accepting writes, writes out all queued entries, stops the background
+// goroutine and closes the underlying writer if it implements io.Closer
+// The standard output streams are never closed
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.cond.Broadcast()
+	a.mu.Unlock()
+	<-a.done
+	return closeUnderlying(a.w)
+}
+
+// enqueue copies e.data into the queue, applying the overflow policy when it is full
+func (a *AsyncWriter) enqueue(e asyncEntry) (int, error) {
+	n := len(e.data)
+	// The caller may reuse p after Write returns
+	e.data = append([]byte(nil), e.data...)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		if a.closed {
+			return 0, ErrWriterClosed
+		}
+		if a.count < len(a.ring) {
+			break
+		}
+		switch a.opts.Policy {
+		case DropNewest:
+			atomic.AddUint64(&a.dropped, 1)
+			return n, nil
+		case DropOldest:
+			a.ring[a.head] = asyncEntry{}
+			a.head = (a.head + 1) % len(a.ring)
+			a.count--
+			atomic.AddUint64(&a.dropped, 1)
+			continue
+		case DropBelowLevel:
+			if e.leveled && e.level < a.opts.DropLevel {
+				atomic.AddUint64(&a.dropped, 1)
+				return n, nil
+			}
+		}
+		a.cond.Wait()
+	}
+	a.ring[(a.head+a.count)%len(a.ring)] = e
+	a.count++
+	a.cond.Broadcast()
+	return n, nil
+}
+
+// run writes queued entries in batches until the writer is closed and drained
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	batch := make([]asyncEntry, 0, len(a.ring))
+	for {
+		a.mu.Lock()
+		for a.count == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.count == 0 {
+			a.mu.Unlock()
+			return
+		}
+		// Move everything queued so far into the batch and free the queue
+		for ; a.count > 0; a.count-- {
+			batch = append(batch, a.ring[a.head])
+			a.ring[a.head] = asyncEntry{}
+			a.head = (a.head + 1) % len(a.ring)
+		}
+		a.busy = true
+		a.cond.Broadcast()
+		a.mu.Unlock()
+
+		for i, e := range batch {
+			if _, err := a.w.Write(e.data); err != nil && a.opts.OnError != nil {
+				a.opts.OnError(err)
+			}
+			batch[i] = asyncEntry{}
+		}
+		batch = batch[:0]
+
+		a.mu.Lock()
+		a.busy = false
+		a.cond.Broadcast()
+		a.mu.Unlock()
+	}
+}
+
+// flushUnderlying flushes w if it buffers data itself
+func flushUnderlying(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// syncUnderlying flushes w and commits its contents to stable storage if it supports that
+// The standard output streams are not synced, which fails on terminals and pipes
+func syncUnderlying(w io.Writer) error {
+	if err := flushUnderlying(w); err != nil {
+		return err
+	}
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	if s, ok := w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// closeUnderlying closes w if it implements io.Closer, except for the standard output streams
+func closeUnderlying(w io.Writer) error {
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package logx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter blocks every Write until the gate is opened
+type gateWriter struct {
+	safeWriter
+	gate    chan struct{}
+	started chan struct{} // Receives a value when a Write starts waiting
+	closed  bool
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{gate: make(chan struct{}), started: make(chan struct{}, 1000)}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.gate
+	return w.safeWriter.Write(p)
+}
+
+func (w *gateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+// fillBlocked queues one entry that the background goroutine picks up and blocks on,
+// then fills the queue, so the next write finds it full
+func fillBlocked(t *testing.T, a *AsyncWriter, w *gateWriter, size int) {
+	t.Helper()
+	_, _ = a.WriteLevel(LevelInfo, []byte("in-flight\n"))
+	<-w.started
+	for i := 0; i < size; i++ {
+		_, _ = a.WriteLevel(LevelInfo, []byte(fmt.Sprintf("queued %d\n", i)))
+	}
+}
+
+func TestAsyncWriter(t *testing.T) {
+	// Test that entries are written in order and Close drains the queue
+	w := &safeWriter{}
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 4})
+
+	var expected strings.Builder
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		expected.WriteString(line)
+		if _, err := a.Write([]byte(line)); err != nil {
+			t.Fatal("Expected no error from Write, got:", err)
+		}
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal("Expected no error from Close, got:", err)
+	}
+
+	if w.String() != expected.String() {
+		t.Fatal("Expected all lines in order, got:", w.String())
+	}
+	if a.Dropped() != 0 {
+		t.Fatalf("Expected no dropped entries with Block, got %d", a.Dropped())
+	}
+	if _, err := a.Write([]byte("late")); err != ErrWriterClosed {
+		t.Fatalf("Expected ErrWriterClosed after Close, got %v", err)
+	}
+}
+
+func TestAsyncWriterCopiesInput(t *testing.T) {
+	// Test that callers may reuse their buffer after Write returns
+	w := &safeWriter{}
+	a := NewAsyncWriter(w, AsyncOptions{})
+
+	buf := []byte("first\n")
+	_, _ = a.Write(buf)
+	copy(buf, "XXXXX\n")
+	_ = a.Close()
+
+	if w.String() != "first\n" {
+		t.Fatal("Expected the original content, got:", w.String())
+	}
+}
+
+func TestAsyncWriterDropNewest(t *testing.T) {
+	// Test discarding new entries when the queue is full
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 2, Policy: DropNewest})
+	fillBlocked(t, a, w, 2)
+
+	if n, err := a.Write([]byte("dropped\n")); err != nil || n != len("dropped\n") {
+		t.Fatalf("Expected dropped write to report success, got n=%d err=%v", n, err)
+	}
+	if a.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped entry, got %d", a.Dropped())
+	}
+
+	close(w.gate)
+	_ = a.Close()
+	if w.String() != "in-flight\nqueued 0\nqueued 1\n" {
+		t.Fatal("Unexpected output:", w.String())
+	}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	// Test discarding the oldest queued entries when the queue is full
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 2, Policy: DropOldest})
+	fillBlocked(t, a, w, 2)
+
+	_, _ = a.Write([]byte("newest\n"))
+	if a.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped entry, got %d", a.Dropped())
+	}
+
+	close(w.gate)
+	_ = a.Close()
+	if w.String() != "in-flight\nqueued 1\nnewest\n" {
+		t.Fatal("Unexpected output:", w.String())
+	}
+}
+
+func TestAsyncWriterDropBelowLevel(t *testing.T) {
+	// Test discarding low level entries while keeping important ones
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 2, Policy: DropBelowLevel, DropLevel: LevelWarn})
+	fillBlocked(t, a, w, 2)
+
+	_, _ = a.WriteLevel(LevelInfo, []byte("info dropped\n"))
+	if a.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped entry, got %d", a.Dropped())
+	}
+
+	// An ERROR entry waits for space instead of being dropped
+	written := make(chan struct{})
+	go func() {
+		_, _ = a.WriteLevel(LevelError, []byte("error kept\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Expected the ERROR entry to wait while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.gate)
+	<-written
+	_ = a.Close()
+	if w.String() != "in-flight\nqueued 0\nqueued 1\nerror kept\n" {
+		t.Fatal("Unexpected output:", w.String())
+	}
+	if a.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped entry, got %d", a.Dropped())
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	// Test that writers wait for space with the Block policy
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 1})
+	fillBlocked(t, a, w, 1)
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = a.Write([]byte("waiting\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Expected the write to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.gate)
+	<-written
+	_ = a.Close()
+	if w.String() != "in-flight\nqueued 0\nwaiting\n" {
+		t.Fatal("Unexpected output:", w.String())
+	}
+}
+
+func TestAsyncWriterFlush(t *testing.T) {
+	// Test waiting for queued entries and honoring the context
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{})
+	_, _ = a.Write([]byte("pending\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := a.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded while the writer is blocked, got %v", err)
+	}
+
+	close(w.gate)
+	if err := a.Flush(context.Background()); err != nil {
+		t.Fatal("Expected no error from Flush, got:", err)
+	}
+	if w.String() != "pending\n" {
+		t.Fatal("Expected pending entry to be written after Flush, got:", w.String())
+	}
+
+	_ = a.Close()
+	if !w.closed {
+		t.Fatal("Expected Close to close the underlying writer")
+	}
+}
+
+func TestAsyncWriterOnError(t *testing.T) {
+	// Test that write errors are reported through OnError
+	var mu sync.Mutex
+	var reported []error
+	a := NewAsyncWriter(errorWriter{}, AsyncOptions{OnError: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}})
+	_, _ = a.Write([]byte("x"))
+	_ = a.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 {
+		t.Fatalf("Expected 1 reported error, got %v", reported)
+	}
+}
+
+// errorWriter fails every write
+type errorWriter struct{}
+
+func (errorWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestLoggerWithAsyncWriter(t *testing.T) {
+	// Test that Logger passes entry levels to level-aware writers
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 1, Policy: DropBelowLevel, DropLevel: LevelError})
+	logger := New(a)
+	logger.SetFormatter(func(entry LogEntry) []byte {
+		return []byte(entry.Message + "\n")
+	})
+
+	logger.Info("in-flight")
+	<-w.started
+	logger.Info("queued")
+	logger.Warn("dropped")
+
+	close(w.gate)
+	_ = a.Close()
+	if w.String() != "in-flight\nqueued\n" {
+		t.Fatal("Unexpected output:", w.String())
+	}
+	if a.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped entry, got %d", a.Dropped())
+	}
+}
@@ -0,0 +1,85 @@
+package logx
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode controls whether a Logger emits ANSI color escapes
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // Decide from the Logger's writer and the NO_COLOR/FORCE_COLOR environment variables
+	ColorAlways                  // Always emit colors
+	ColorNever                   // Never emit colors
+)
+
+// String returns the string representation of the color mode
+func (m ColorMode) String() string {
+	switch m {
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	default:
+		return "auto"
+	}
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+// It accepts the names returned by String, case-insensitively
+func (m *ColorMode) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "auto":
+		*m = ColorAuto
+	case "always":
+		*m = ColorAlways
+	case "never":
+		*m = ColorNever
+	default:
+		return fmt.Errorf("logx: unknown color mode %q", text)
+	}
+	return nil
+}
+
+// useColor decides whether output written to w should be colored
+// In ColorAuto mode:
+// 1. A non-empty NO_COLOR disables colors (https://no-color.org)
+// 2. A FORCE_COLOR other than "", "0" or "false" enables colors
+// 3. Otherwise colors are used only when w is a terminal and TERM is not "dumb"
+func useColor(mode ColorMode, w io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := strings.ToLower(os.Getenv("FORCE_COLOR")); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// paint renders s with c when enabled is true, and returns s unchanged otherwise
+// The decision is made per entry, independent of the global color.NoColor state
+func paint(c *color.Color, s string, enabled bool) string {
+	if !enabled {
+		return s
+	}
+	c.EnableColor()
+	return c.Sprint(s)
+}
@@ -0,0 +1,105 @@
+package logx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUseColor(t *testing.T) {
+	// Test color decision from mode and environment
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	buffer := &bytes.Buffer{}
+	if useColor(ColorAuto, buffer) {
+		t.Fatal("Expected no color for a non-terminal writer")
+	}
+	if !useColor(ColorAlways, buffer) {
+		t.Fatal("Expected color with ColorAlways")
+	}
+	if useColor(ColorNever, os.Stderr) {
+		t.Fatal("Expected no color with ColorNever")
+	}
+
+	t.Setenv("FORCE_COLOR", "1")
+	if !useColor(ColorAuto, buffer) {
+		t.Fatal("Expected FORCE_COLOR to enable color")
+	}
+
+	t.Setenv("FORCE_COLOR", "0")
+	if useColor(ColorAuto, buffer) {
+		t.Fatal("Expected FORCE_COLOR=0 not to enable color")
+	}
+
+	t.Setenv("FORCE_COLOR", "1")
+	t.Setenv("NO_COLOR", "1")
+	if useColor(ColorAuto, buffer) {
+		t.Fatal("Expected NO_COLOR to take precedence")
+	}
+	if !useColor(ColorAlways, buffer) {
+		t.Fatal("Expected ColorAlways to override NO_COLOR")
+	}
+}
+
+func TestFileOutputHasNoColor(t *testing.T) {
+	// Test that files never receive ANSI escapes
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal("Expected no error creating file, got:", err)
+	}
+
+	logger := New(file)
+	logger.SetPrefix("TEST")
+	logger.Infow("file message", "k", "v")
+	logger.Error("error message")
+	_ = file.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("Expected no error reading file, got:", err)
+	}
+	if strings.Contains(string(data), "\x1b[") {
+		t.Fatalf("Expected no ANSI escapes in file output, got %q", data)
+	}
+	if !strings.Contains(string(data), "file message") {
+		t.Fatalf("Expected message in file output, got %q", data)
+	}
+}
+
+func TestSetColor(t *testing.T) {
+	// Test explicit color override
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+
+	logger.Info("plain")
+	if strings.Contains(buffer.String(), "\x1b[") {
+		t.Fatalf("Expected no ANSI escapes for a buffer, got %q", buffer.String())
+	}
+
+	buffer.Reset()
+	logger.SetColor(ColorAlways)
+	logger.Info("colored")
+	if !strings.Contains(buffer.String(), "\x1b[") {
+		t.Fatalf("Expected ANSI escapes with ColorAlways, got %q", buffer.String())
+	}
+
+	// The decision follows the writer when it changes
+	other := &bytes.Buffer{}
+	logger.SetColor(ColorAuto)
+	logger.SetOutput(other)
+	logger.Info("plain again")
+	if strings.Contains(other.String(), "\x1b[") {
+		t.Fatalf("Expected no ANSI escapes after switching back to ColorAuto, got %q", other.String())
+	}
+}
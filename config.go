@@ -0,0 +1,380 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chihqiang/logx/rotate"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a Logger declaratively, e.g. in YAML:
+//
+//	level: info
+//	prefix: api
+//	vmodule: db=debug
+//	format: text
+//	sinks:
+//	  - output: logs/app.log
+//	    format: json
+//	    rotate: {max_size: 104857600, interval: daily, max_backups: 7, compress: gzip}
+//	  - output: stdout
+//	    level: error
+//
+// The config describes the whole state, empty fields select the defaults of New
+type Config struct {
+	Level        string           `json:"level" yaml:"level"`     // Minimum level, default "debug"
+	Prefix       string           `json:"prefix" yaml:"prefix"`   // Log prefix
+	VModule      string           `json:"vmodule" yaml:"vmodule"` // Rules for SetVModule
+	OutputConfig `yaml:",inline"` // Primary output
+	Sinks        []SinkConfig     `json:"sinks" yaml:"sinks"` // Additional outputs
+}
+
+// OutputConfig describes where and how entries are written
+type OutputConfig struct {
+	Output     string        `json:"output" yaml:"output"`           // "stderr" (default), "stdout" or a file path to append to
+	Format     string        `json:"format" yaml:"format"`           // "text" (default), "json", "logfmt" or "xml"
+	TimeFormat string        `json:"time_format" yaml:"time_format"` // Time layout for text and json, or "unixmilli" for json
+	Color      string        `json:"color" yaml:"color"`             // "auto" (default), "always" or "never"
+	Rotate     *RotateConfig `json:"rotate" yaml:"rotate"`           // Rotate the output file, requires a file path
+}
+
+// SinkConfig describes an additional output with its own minimum level
+type SinkConfig struct {
+	OutputConfig `yaml:",inline"`
+	Level        string `json:"level" yaml:"level"` // Minimum level, default "debug"
+}
+
+// RotateConfig describes rotate.Options for a file output
+type RotateConfig struct {
+	MaxSize      int64  `json:"max_size" yaml:"max_size"`             // Rotate before the file exceeds this many bytes
+	Interval     string `json:"interval" yaml:"interval"`             // "never" (default), "hourly" or "daily"
+	MaxBackups   int    `json:"max_backups" yaml:"max_backups"`       // Keep at most this many rotated files
+	MaxAge       string `json:"max_age" yaml:"max_age"`               // Remove rotated files older than this, e.g. "720h"
+	MaxTotalSize int64  `json:"max_total_size" yaml:"max_total_size"` // Disk budget for rotated files in bytes
+	Compress     string `json:"compress" yaml:"compress"`             // "none" (default), "gzip" or "zstd"
+	Symlink      string `json:"symlink" yaml:"symlink"`               // Symlink kept pointing at the current file
+}
+
+// LoadConfig reads a Config from a JSON or YAML file, chosen by its extension
+// Unknown keys are rejected, errors name the file and the position in it
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := parseConfig(data, filepath.Ext(path))
+	if err != nil {
+		return Config{}, fmt.Errorf("logx: config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parseConfig decodes a Config in the format given by a file extension
+func parseConfig(data []byte, ext string) (Config, error) {
+	var cfg Config
+	switch strings.ToLower(ext) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&cfg); err != nil {
+			return Config{}, jsonPosition(data, decoder, err)
+		}
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		// An empty file is an empty config
+		if err := decoder.Decode(&cfg); err != nil && err != io.EOF {
+			return Config{}, err
+		}
+	default:
+		return Config{}, fmt.Errorf("unknown config extension %q, expected .json, .yaml or .yml", ext)
+	}
+	return cfg, nil
+}
+
+// jsonPosition adds the line and column to a JSON decoding error
+func jsonPosition(data []byte, decoder *json.Decoder, err error) error {
+	offset := decoder.InputOffset()
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+	// Offsets point just past the offending byte
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset > 0 {
+		offset--
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+// builtConfig is a validated Config with its writers opened, ready to be applied
+type builtConfig struct {
+	level     Level
+	prefix    string
+	vmodule   *vmodule
+	writer    io.Writer
+	formatter Formatter
+	colorMode ColorMode
+	sinks     []*Sink
+	closers   []io.Closer // Files opened for the config
+}
+
+// build validates cfg and opens its outputs
+// Nothing is left open if it fails, errors name the offending field
+func (cfg Config) build() (_ *builtConfig, err error) {
+	b := &builtConfig{level: LevelDebug, prefix: cfg.Prefix}
+	defer func() {
+		if err != nil {
+			for _, c := range b.closers {
+				_ = c.Close()
+			}
+		}
+	}()
+	if cfg.Level != "" {
+		if b.level, err = ParseLevel(cfg.Level); err != nil {
+			return nil, fmt.Errorf("level: %w", err)
+		}
+	}
+	if b.vmodule, err = parseVModule(cfg.VModule); err != nil {
+		return nil, fmt.Errorf("vmodule: %w", err)
+	}
+	if b.writer, b.formatter, b.colorMode, err = b.open(cfg.OutputConfig); err != nil {
+		return nil, err
+	}
+	for i, sc := range cfg.Sinks {
+		level := LevelDebug
+		if sc.Level != "" {
+			if level, err = ParseLevel(sc.Level); err != nil {
+				return nil, fmt.Errorf("sinks[%d].level: %w", i, err)
+			}
+		}
+		w, fn, mode, err := b.open(sc.OutputConfig)
+		if err != nil {
+			return nil, fmt.Errorf("sinks[%d].%w", i, err)
+		}
+		b.sinks = append(b.sinks, &Sink{writer: w, formatter: fn, level: level, color: useColor(mode, w)})
+	}
+	return b, nil
+}
+
+// open validates an output and opens its writer, recording files in b.closers
+// Errors start with the name of the offending field
+func (b *builtConfig) open(oc OutputConfig) (w io.Writer, fn Formatter, mode ColorMode, err error) {
+	if fn, err = newFormatter(strings.ToLower(oc.Format), oc.TimeFormat); err != nil {
+		return nil, nil, 0, fmt.Errorf("format: %w", err)
+	}
+	if oc.Color != "" {
+		if err = mode.UnmarshalText([]byte(oc.Color)); err != nil {
+			return nil, nil, 0, fmt.Errorf("color: %w", err)
+		}
+	}
+	switch oc.Output {
+	case "", "stderr", "stdout":
+		if oc.Rotate != nil {
+			return nil, nil, 0, errors.New("rotate: requires a file output")
+		}
+		if oc.Output == "stdout" {
+			return os.Stdout, fn, mode, nil
+		}
+		return os.Stderr, fn, mode, nil
+	}
+	var file io.WriteCloser
+	if oc.Rotate != nil {
+		opts, err := oc.Rotate.options()
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("rotate.%w", err)
+		}
+		if file, err = rotate.New(oc.Output, opts); err != nil {
+			return nil, nil, 0, fmt.Errorf("output: %w", err)
+		}
+	} else {
+		if file, err = os.OpenFile(oc.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+			return nil, nil, 0, fmt.Errorf("output: %w", err)
+		}
+	}
+	b.closers = append(b.closers, file)
+	return file, fn, mode, nil
+}
+
+// options converts the config to rotate.Options
+// Errors start with the name of the offending field
+func (rc RotateConfig) options() (rotate.Options, error) {
+	opts := rotate.Options{
+		MaxSize:      rc.MaxSize,
+		MaxBackups:   rc.MaxBackups,
+		MaxTotalSize: rc.MaxTotalSize,
+		Symlink:      rc.Symlink,
+	}
+	switch strings.ToLower(rc.Interval) {
+	case "", "never":
+	case "hourly":
+		opts.Interval = rotate.Hourly
+	case "daily":
+		opts.Interval = rotate.Daily
+	default:
+		return opts, fmt.Errorf("interval: unknown interval %q", rc.Interval)
+	}
+	if rc.MaxAge != "" {
+		age, err := time.ParseDuration(rc.MaxAge)
+		if err != nil {
+			return opts, fmt.Errorf("max_age: %w", err)
+		}
+		opts.MaxAge = age
+	}
+	switch strings.ToLower(rc.Compress) {
+	case "", "none":
+	case "gzip":
+		opts.Compress = rotate.Gzip
+	case "zstd":
+		opts.Compress = rotate.Zstd
+	default:
+		return opts, fmt.Errorf("compress: unknown compression %q", rc.Compress)
+	}
+	for _, limit := range []struct {
+		name  string
+		value int64
+	}{
+		{"max_size", rc.MaxSize},
+		{"max_backups", int64(rc.MaxBackups)},
+		{"max_age", int64(opts.MaxAge)},
+		{"max_total_size", rc.MaxTotalSize},
+	} {
+		if limit.value < 0 {
+			return opts, fmt.Errorf("%s: must not be negative", limit.name)
+		}
+	}
+	return opts, nil
+}
+
+// NewFromConfig creates a Logger described by cfg
+func NewFromConfig(cfg Config) (*Logger, error) {
+	l := New(os.Stderr)
+	if err := l.ApplyConfig(cfg); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ApplyConfig replaces the level, prefix, vmodule rules, output, formatter and
+// sinks of l with those described by cfg (thread-safe)
+// cfg is validated and its files are opened first, so an invalid config leaves l unchanged
+// The output state is swapped at once, so concurrent log calls see either the old
+// or the new config, and files opened by the previous config are closed after
+// the writes in progress with them have finished
+// The change is visible to all loggers sharing the same output state
+func (l *Logger) ApplyConfig(cfg Config) error {
+	b, err := cfg.build()
+	if err != nil {
+		return fmt.Errorf("logx: invalid config: %w", err)
+	}
+
+	l.core.mu.Lock()
+	l.core.own |= ownWriter | ownColor | ownFormatter | ownSinks
+	l.core.writer = b.writer
+	l.core.formatter = b.formatter
+	l.core.colorMode = b.colorMode
+	l.core.color = useColor(b.colorMode, b.writer)
+	outputChanged()
+	l.core.sinks = b.sinks
+	l.core.vmodule.Store(b.vmodule)
+	l.SetLevel(b.level)
+	l.mu.Lock()
+	l.prefix = b.prefix
+	l.mu.Unlock()
+	closers, inflight := l.core.closers, l.core.inflight
+	l.core.closers, l.core.inflight = b.closers, new(sync.WaitGroup)
+	l.core.mu.Unlock()
+
+	// New writes use the new writers, wait for the old ones to finish before closing
+	inflight.Wait()
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c.Close())
+	}
+	return joinErrors(errs...)
+}
+
+// WatchConfig applies the config file at path to l, then polls the file's
+// modification time and size every interval and applies it again when they change
+// A config that fails to load or apply is reported to onError and the previous
+// config keeps running, onError defaults to logging the error on l
+// It returns the error of the first load, and otherwise a function to stop watching
+func (l *Logger) WatchConfig(path string, interval time.Duration, onError func(err error)) (stop func(), err error) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	if onError == nil {
+		onError = func(err error) { l.Errorw("logx: config reload failed", "error", err) }
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.applyConfigFile(path); err != nil {
+		return nil, err
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		modTime, size := info.ModTime(), info.Size()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				onError(err)
+				continue
+			}
+			if info.ModTime().Equal(modTime) && info.Size() == size {
+				continue
+			}
+			// Remember the change even if it fails, so it is reported once
+			modTime, size = info.ModTime(), info.Size()
+			if err := l.applyConfigFile(path); err != nil {
+				onError(err)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(quit)
+			<-done
+		})
+	}, nil
+}
+
+// applyConfigFile loads the config file at path and applies it to l
+func (l *Logger) applyConfigFile(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := l.ApplyConfig(cfg); err != nil {
+		return fmt.Errorf("%w (%s)", err, path)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package logx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file into dir and returns its path
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readLog returns the content of a log file
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLoadConfig(t *testing.T) {
+	// Test that YAML and JSON describe the same Config
+	dir := t.TempDir()
+	yamlPath := writeConfig(t, dir, "log.yaml", `
+level: info
+prefix: api
+vmodule: db=debug
+format: json
+time_format: unixmilli
+sinks:
+  - output: app.log
+    level: error
+    rotate: {max_size: 1024, interval: daily, max_age: 24h, compress: gzip}
+`)
+	jsonPath := writeConfig(t, dir, "log.json", `{
+	"level": "info", "prefix": "api", "vmodule": "db=debug", "format": "json", "time_format": "unixmilli",
+	"sinks": [{"output": "app.log", "level": "error",
+		"rotate": {"max_size": 1024, "interval": "daily", "max_age": "24h", "compress": "gzip"}}]
+}`)
+
+	fromYAML, err := LoadConfig(yamlPath)
+	if err != nil {
+		t.Fatal("Expected no error loading YAML, got:", err)
+	}
+	fromJSON, err := LoadConfig(jsonPath)
+	if err != nil {
+		t.Fatal("Expected no error loading JSON, got:", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Fatalf("Expected identical configs, got\n%+v\n%+v", fromYAML, fromJSON)
+	}
+	if fromYAML.Format != "json" || fromYAML.Sinks[0].Level != "error" || fromYAML.Sinks[0].Rotate.MaxAge != "24h" {
+		t.Fatalf("Unexpected config %+v", fromYAML)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	// Test building a Logger with a primary output and sinks
+	dir := t.TempDir()
+	all, errs := filepath.Join(dir, "all.log"), filepath.Join(dir, "errors.log")
+	logger, err := NewFromConfig(Config{
+		Level:        "info",
+		Prefix:       "api",
+		OutputConfig: OutputConfig{Output: all, Format: "logfmt"},
+		Sinks:        []SinkConfig{{OutputConfig: OutputConfig{Output: errs, Format: "json", Rotate: &RotateConfig{MaxSize: 1 << 20}}, Level: "error"}},
+	})
+	if err != nil {
+		t.Fatal("Expected no error from NewFromConfig, got:", err)
+	}
+	logger.Debug("hidden")
+	logger.Info("started")
+	logger.Error("failed")
+	if err := logger.Close(); err != nil {
+		t.Fatal("Expected no error from Close, got:", err)
+	}
+
+	if out := readLog(t, all); strings.Contains(out, "hidden") || !strings.Contains(out, "level=info prefix=api") ||
+		!strings.Contains(out, "msg=failed") {
+		t.Fatal("Unexpected primary output:", out)
+	}
+	rotated, _ := filepath.Glob(filepath.Join(dir, "errors-*.log"))
+	if len(rotated) != 1 {
+		t.Fatal("Expected one rotated file, got:", rotated)
+	}
+	if out := readLog(t, rotated[0]); strings.Contains(out, "started") || !strings.Contains(out, `"message":"failed"`) {
+		t.Fatal("Unexpected sink output:", out)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	// Test that invalid configs are rejected with the offending field or position
+	dir := t.TempDir()
+	tests := []struct {
+		name, content, expected string
+	}{
+		{"a.yaml", "level: info\nformats: json\n", "line 2: field formats not found"},
+		{"b.json", "{\n  \"level\": \"info\",\n  \"sinks\": 3\n}", "line 3, column"},
+		{"c.json", `{"level": "info",}`, "line 1, column 18"},
+		{"d.toml", "level = 'info'", "unknown config extension"},
+		{"e.yaml", "level: verbose\n", "level: logx: unknown level name"},
+		{"f.yaml", "sinks:\n  - output: stdout\n  - format: yaml\n", "sinks[1].format: logx: unknown format"},
+		{"g.yaml", "sinks:\n  - level: loud\n", "sinks[0].level"},
+		{"h.yaml", "rotate: {max_size: 10}\n", "rotate: requires a file output"},
+		{"i.yaml", "output: x.log\nrotate: {interval: weekly}\n", "rotate.interval: unknown interval"},
+		{"j.yaml", "output: x.log\nrotate: {max_age: forever}\n", "rotate.max_age"},
+		{"k.yaml", "sinks:\n  - output: x.log\n    rotate: {max_backups: -1}\n", "sinks[0].rotate.max_backups: must not be negative"},
+		{"l.yaml", "vmodule: db\n", "vmodule: logx: invalid vmodule rule"},
+		{"m.yaml", "format: logfmt\ntime_format: '15:04'\n", "format: logx: the logfmt format does not support a time format"},
+		{"n.yaml", "color: sometimes\n", "color: logx: unknown color mode"},
+		{"o.yaml", "output: " + filepath.Join(dir, "missing", "x.log") + "\n", "output: open"},
+	}
+	for _, tt := range tests {
+		path := writeConfig(t, dir, tt.name, tt.content)
+		cfg, err := LoadConfig(path)
+		if err == nil {
+			_, err = NewFromConfig(cfg)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestApplyConfigInvalidKeepsOld(t *testing.T) {
+	// Test that an invalid config leaves the Logger unchanged
+	var out safeWriter
+	logger := New(&out)
+	logger.SetLevel(LevelWarn)
+	logger.SetPrefix("old")
+
+	err := logger.ApplyConfig(Config{Level: "info", Prefix: "new", Sinks: []SinkConfig{{Level: "loud"}}})
+	if err == nil || !strings.Contains(err.Error(), "sinks[0].level") {
+		t.Fatal("Expected the sink level to be rejected, got:", err)
+	}
+	logger.Warn("still here")
+	if logger.GetLevel() != LevelWarn || !strings.Contains(out.String(), "old: still here") {
+		t.Fatal("Expected the old config to keep running, got:", out.String())
+	}
+}
+
+func TestApplyConfigConcurrent(t *testing.T) {
+	// Test that switching files while logging neither drops nor corrupts entries
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")}
+	logger, err := NewFromConfig(Config{OutputConfig: OutputConfig{Output: paths[0], Format: "logfmt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines, lines = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < lines; i++ {
+				if err := logger.Logw(LevelInfo, "line", "g", g, "i", i); err != nil {
+					t.Error("Expected no write error, got:", err)
+					return
+				}
+			}
+		}(g)
+	}
+	for i := 0; i < 50; i++ {
+		if err := logger.ApplyConfig(Config{OutputConfig: OutputConfig{Output: paths[i%2], Format: "logfmt"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	_ = logger.Close()
+
+	total := 0
+	for _, p := range paths {
+		for _, line := range strings.Split(strings.TrimSpace(readLog(t, p)), "\n") {
+			if line == "" {
+				continue
+			}
+			if _, err := ParseLogfmt([]byte(line)); err != nil {
+				t.Fatalf("Corrupt line %q: %v", line, err)
+			}
+			total++
+		}
+	}
+	if total != goroutines*lines {
+		t.Fatalf("Expected %d lines, got %d", goroutines*lines, total)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	// Test that changes to the file are applied and broken ones are reported
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "log.yaml", "level: info\n")
+	logger := New(&safeWriter{})
+
+	errs := make(chan error, 10)
+	stop, err := logger.WatchConfig(path, 5*time.Millisecond, func(err error) { errs <- err })
+	if err != nil {
+		t.Fatal("Expected no error from WatchConfig, got:", err)
+	}
+	defer stop()
+	if logger.GetLevel() != LevelInfo {
+		t.Fatal("Expected the config to be applied immediately, got:", logger.GetLevel())
+	}
+
+	// touch rewrites the file with a distinct modification time
+	mtime := time.Now()
+	touch := func(content string) {
+		mtime = mtime.Add(time.Second)
+		writeConfig(t, dir, "log.yaml", content)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	touch("level: error\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for logger.GetLevel() != LevelError {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the changed config to be applied")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	touch("level: loud\n")
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "log.yaml") || !strings.Contains(err.Error(), "loud") {
+			t.Fatal("Expected the error to name the file and value, got:", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the broken config to be reported")
+	}
+	if logger.GetLevel() != LevelError {
+		t.Fatal("Expected the previous config to keep running")
+	}
+
+	if _, err := logger.WatchConfig(filepath.Join(dir, "missing.yaml"), 0, nil); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
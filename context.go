@@ -0,0 +1,105 @@
+package logx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// contextKey is the key of the Logger stored in a context by NewContext
+type contextKey struct{}
+
+// ContextExtractor returns fields to attach to entries logged with a context, e.g. a
+// request ID stored in the context by a middleware, or nil if it has none
+// Extractors run only for entries that pass the level check
+type ContextExtractor func(ctx context.Context) Fields
+
+var (
+	extractorMu sync.Mutex   // Serializes RegisterContextExtractor
+	extractors  atomic.Value // Current []ContextExtractor, replaced as a whole so log calls need no lock
+)
+
+// RegisterContextExtractor adds an extractor whose fields are added to every entry
+// logged with a ...Context method, after the fields of the Logger and in the order
+// the extractors were registered
+// It is safe to call concurrently with logging, but is meant to be called during initialization
+func RegisterContextExtractor(fn ContextExtractor) {
+	if fn == nil {
+		return
+	}
+	extractorMu.Lock()
+	defer extractorMu.Unlock()
+	old, _ := extractors.Load().([]ContextExtractor)
+	// Copy on write so log calls can use the slice without holding the lock
+	fns := make([]ContextExtractor, 0, len(old)+1)
+	extractors.Store(append(append(fns, old...), fn))
+}
+
+// contextFields returns the fields of all registered extractors for ctx
+func contextFields(ctx context.Context) Fields {
+	fns, _ := extractors.Load().([]ContextExtractor)
+	if ctx == nil || len(fns) == 0 {
+		return nil
+	}
+	var fields Fields
+	for _, fn := range fns {
+		fields = append(fields, fn(ctx)...)
+	}
+	return fields
+}
+
+// NewContext returns a copy of ctx carrying l, to be retrieved with FromContext
+// e.g. a middleware can store logger.With("request_id", id) for the whole request
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger stored in ctx by NewContext, or the global Logger
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok && l != nil {
+			return l
+		}
+	}
+	return _std()
+}
+
+// TraceContext outputs Trace level logs with the fields extracted from ctx
+func (l *Logger) TraceContext(ctx context.Context, format string, v ...interface{}) {
+	_ = l.logContext(ctx, LevelTrace, format, v...)
+}
+
+// DebugContext outputs Debug level logs with the fields extracted from ctx
+func (l *Logger) DebugContext(ctx context.Context, format string, v ...interface{}) {
+	_ = l.logContext(ctx, LevelDebug, format, v...)
+}
+
+// InfoContext outputs Info level logs with the fields extracted from ctx
+func (l *Logger) InfoContext(ctx context.Context, format string, v ...interface{}) {
+	_ = l.logContext(ctx, LevelInfo, format, v...)
+}
+
+// WarnContext outputs Warn level logs with the fields extracted from ctx
+func (l *Logger) WarnContext(ctx context.Context, format string, v ...interface{}) {
+	_ = l.logContext(ctx, LevelWarn, format, v...)
+}
+
+// ErrorContext outputs Error level logs with the fields extracted from ctx
+func (l *Logger) ErrorContext(ctx context.Context, format string, v ...interface{}) {
+	_ = l.logContext(ctx, LevelError, format, v...)
+}
+
+// LogContext outputs logs at the specified level with the fields extracted from ctx
+func (l *Logger) LogContext(ctx context.Context, level Level, format string, v ...interface{}) error {
+	return l.logContext(ctx, level, format, v...)
+}
+
+// logContext outputs printf-style logs at the specified level with the fields extracted from ctx
+// It returns early if the level is disabled, before running the extractors
+func (l *Logger) logContext(ctx context.Context, level Level, format string, v ...interface{}) error {
+	if !l.enabled(level) {
+		return nil
+	}
+	return l.output(ctx, level, fmt.Sprintf(format, v...), contextFields(ctx))
+}
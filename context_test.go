@@ -0,0 +1,94 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+// withExtractors replaces the registered extractors for the duration of a test
+func withExtractors(t *testing.T, fns ...ContextExtractor) {
+	t.Helper()
+	old, _ := extractors.Load().([]ContextExtractor)
+	extractors.Store([]ContextExtractor(nil))
+	for _, fn := range fns {
+		RegisterContextExtractor(fn)
+	}
+	t.Cleanup(func() { extractors.Store(old) })
+}
+
+func TestContextExtractors(t *testing.T) {
+	// Test that extracted fields follow the Logger's fields, in registration order
+	calls := 0
+	withExtractors(t,
+		func(ctx context.Context) Fields {
+			calls++
+			if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+				return Fields{{Key: "request_id", Value: id}}
+			}
+			return nil
+		},
+		func(ctx context.Context) Fields { return Fields{{Key: "tenant", Value: "acme"}} },
+	)
+	var buffer bytes.Buffer
+	var captured LogEntry
+	logger := New(&buffer)
+	logger.SetFormatter(LogfmtFormatter)
+	logger.AddHook(func(entry *LogEntry) { captured = *entry })
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r-1")
+
+	logger.With("service", "api").InfoContext(ctx, "handled %d", 200)
+	if !strings.Contains(buffer.String(), "msg=\"handled 200\" service=api request_id=r-1 tenant=acme") {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+	if captured.Context != ctx || filepath.Base(captured.File) != "context_test.go" {
+		t.Fatalf("Expected the context and caller on the entry, got %v at %s", captured.Context, captured.File)
+	}
+
+	// Extractors do not run for disabled levels
+	logger.SetLevel(LevelInfo)
+	logger.DebugContext(ctx, "hidden")
+	if calls != 1 {
+		t.Fatal("Expected extractors to run once, got:", calls)
+	}
+
+	// Methods without a context carry none
+	buffer.Reset()
+	logger.Info("plain")
+	if captured.Context != nil || strings.Contains(buffer.String(), "request_id") {
+		t.Fatal("Expected no context fields, got:", buffer.String())
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	// Test that the global ...Context functions log with the Logger stored in the context
+	withExtractors(t)
+	var global, request bytes.Buffer
+	std = nil
+	stdOnce = sync.Once{}
+	stdOnce.Do(func() { std = New(&global) })
+	defer func() { std = nil; stdOnce = sync.Once{} }()
+
+	if FromContext(context.Background()) != Default() {
+		t.Fatal("Expected FromContext to fall back to the global Logger")
+	}
+	logger := New(&request).With("request_id", "r-2")
+	ctx := NewContext(context.Background(), logger)
+	if FromContext(ctx) != logger {
+		t.Fatal("Expected FromContext to return the stored Logger")
+	}
+
+	WarnContext(ctx, "slow")
+	ErrorContext(context.Background(), "failed")
+	if !strings.Contains(request.String(), "[context_test.go:") || !strings.Contains(request.String(), "slow request_id=r-2") {
+		t.Fatal("Unexpected request output:", request.String())
+	}
+	if !strings.Contains(global.String(), "failed") || strings.Contains(global.String(), "slow") {
+		t.Fatal("Unexpected global output:", global.String())
+	}
+}
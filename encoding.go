@@ -0,0 +1,251 @@
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// bufferPool holds byte slices reused by the built-in formatters
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// maxPooledBuffer is the largest buffer capacity returned to the pool
+const maxPooledBuffer = 64 << 10
+
+// putBuffer returns a buffer to the pool and copies its content out
+// Formatter results are handed to io.Writer, which must not retain them, but the
+// caller keeps the returned slice so it cannot alias the pooled buffer
+func putBuffer(b *[]byte) []byte {
+	out := make([]byte, len(*b))
+	copy(out, *b)
+	releaseBuffer(b)
+	return out
+}
+
+// releaseBuffer returns a buffer whose content is no longer used to the pool
+func releaseBuffer(b *[]byte) {
+	// Avoid keeping huge buffers alive in the pool
+	if cap(*b) <= maxPooledBuffer {
+		bufferPool.Put(b)
+	}
+}
+
+// maxCachedEncodings limits the encodings kept by a fieldCache, one per formatter in practice
+const maxCachedEncodings = 8
+
+// fieldCache holds the context fields of a Logger derived with With, encoded once by each
+// built-in formatter writing them, so they are not encoded again on every call
+// Nothing is stored for the first entry, so children logging once per request pay
+// nothing for the cache
+type fieldCache struct {
+	mu        sync.Mutex   // Serializes additions
+	used      int32        // Set atomically once the first entry has been written
+	encodings atomic.Value // Current []cachedEncoding, replaced as a whole so lookups need no lock
+}
+
+// cachedEncoding is the encoding of the context fields by one formatter
+type cachedEncoding struct {
+	key  *byte // Identifies the formatter and its variant, e.g. colored or not
+	data []byte
+}
+
+// get returns the encoding of fields by enc, encoding them when they are used a second time
+// It reports false if they are not cached yet and must be encoded by the caller
+func (c *fieldCache) get(key *byte, fields Fields, enc func(buf []byte, fields Fields) []byte) ([]byte, bool) {
+	encodings, _ := c.encodings.Load().([]cachedEncoding)
+	for _, e := range encodings {
+		if e.key == key {
+			return e.data, true
+		}
+	}
+	if atomic.CompareAndSwapInt32(&c.used, 0, 1) {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	encodings, _ = c.encodings.Load().([]cachedEncoding)
+	if len(encodings) >= maxCachedEncodings {
+		return nil, false
+	}
+	data := enc(nil, fields)
+	// Copy on write so lookups can use the slice without holding the lock
+	c.encodings.Store(append(encodings[:len(encodings):len(encodings)], cachedEncoding{key: key, data: data}))
+	return data, true
+}
+
+// appendFields appends the fields of entry encoded by enc
+// The leading fields of the Logger are taken from its fieldCache when the entry has one
+// key identifies the formatter, and enc must encode each field on its own so the
+// encodings of consecutive fields can be concatenated
+func appendFields(buf []byte, entry LogEntry, key *byte, enc func(buf []byte, fields Fields) []byte) []byte {
+	fields := entry.Fields
+	if entry.encoded != nil && entry.cached <= len(fields) {
+		if data, ok := entry.encoded.get(key, fields[:entry.cached], enc); ok {
+			buf = append(buf, data...)
+			fields = fields[entry.cached:]
+		}
+	}
+	return enc(buf, fields)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// appendJSONValue appends v as a JSON value
+// Common types are encoded directly; other types fall back to encoding/json
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, x)
+	case bool:
+		return strconv.AppendBool(buf, x)
+	case int:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(buf, x, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case uint64:
+		return strconv.AppendUint(buf, x, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(x), 32)
+	case float64:
+		return appendJSONFloat(buf, x, 64)
+	case time.Time:
+		return appendJSONString(buf, x.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendJSONString(buf, x.String())
+	case json.Marshaler:
+		if isNilPointer(x) {
+			return append(buf, "null"...)
+		}
+		data, err := x.MarshalJSON()
+		if err != nil {
+			return appendJSONString(buf, fmt.Sprintf("!ERROR: %v", err))
+		}
+		return append(buf, data...)
+	case error:
+		if isNilPointer(x) {
+			return append(buf, "null"...)
+		}
+		return appendJSONString(buf, x.Error())
+	case fmt.Stringer:
+		if isNilPointer(x) {
+			return append(buf, "null"...)
+		}
+		return appendJSONString(buf, x.String())
+	default:
+		data, err := json.Marshal(x)
+		if err != nil {
+			return appendJSONString(buf, fmt.Sprint(x))
+		}
+		return append(buf, data...)
+	}
+}
+
+// isNilPointer reports whether v holds a nil pointer, whose methods may not handle a nil receiver
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// appendJSONFloat appends f as a JSON number, NaN and infinities are written as strings
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(buf, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(buf, `"-Inf"`...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// appendJSONFields appends fields as key/value members of a JSON object
+// A leading comma is written before each member when comma is true
+func appendJSONFields(buf []byte, fields Fields, comma bool) []byte {
+	for _, f := range fields {
+		if comma {
+			buf = append(buf, ',')
+		}
+		comma = true
+		buf = appendJSONString(buf, f.Key)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	return buf
+}
@@ -0,0 +1,113 @@
+package logx
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendJSONString(t *testing.T) {
+	// Test that strings decode back to the same value
+	inputs := []string{
+		"",
+		"plain",
+		`quote " and backslash \`,
+		"line\nbreak\ttab\rreturn",
+		"control \x00\x01\x1f",
+		"unicode 日本語 ✓",
+		"<html> & stuff",
+	}
+
+	for _, in := range inputs {
+		encoded := appendJSONString(nil, in)
+		var decoded string
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Errorf("Invalid JSON %s for %q: %v", encoded, in, err)
+			continue
+		}
+		if decoded != in {
+			t.Errorf("Round trip of %q gave %q", in, decoded)
+		}
+	}
+
+	// Invalid UTF-8 is replaced rather than producing invalid JSON
+	encoded := appendJSONString(nil, "bad \xff byte")
+	var decoded string
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", encoded, err)
+	}
+	if decoded != "bad � byte" {
+		t.Fatalf("Unexpected decoded value %q", decoded)
+	}
+}
+
+type testStringer struct{}
+
+func (testStringer) String() string { return "stringer" }
+
+type ptrStringer struct{ s string }
+
+func (p *ptrStringer) String() string { return p.s }
+
+func TestAppendJSONValue(t *testing.T) {
+	// Test encoding of common value types
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, `null`},
+		{"s", `"s"`},
+		{true, `true`},
+		{-42, `-42`},
+		{int64(math.MinInt64), `-9223372036854775808`},
+		{uint64(math.MaxUint64), `18446744073709551615`},
+		{1.5, `1.5`},
+		{float32(0.25), `0.25`},
+		{math.NaN(), `"NaN"`},
+		{math.Inf(-1), `"-Inf"`},
+		{time.Date(2023, 1, 1, 12, 0, 0, 5, time.UTC), `"2023-01-01T12:00:00.000000005Z"`},
+		{1500 * time.Millisecond, `"1.5s"`},
+		{errors.New("boom"), `"boom"`},
+		{testStringer{}, `"stringer"`},
+		{LevelWarn, `"WARN"`},
+		{[]int{1, 2}, `[1,2]`},
+		{map[string]int{"a": 1}, `{"a":1}`},
+		{(*os.PathError)(nil), `null`},
+		{(*ptrStringer)(nil), `null`},
+		{&ptrStringer{"set"}, `"set"`},
+	}
+
+	for _, tt := range tests {
+		if got := string(appendJSONValue(nil, tt.value)); got != tt.expected {
+			t.Errorf("appendJSONValue(%#v) = %s, expected %s", tt.value, got, tt.expected)
+		}
+	}
+
+	// Typed nil pointers reach the formatter and Fields.MarshalJSON as non-nil interfaces
+	var pathErr *os.PathError
+	fields := Fields{{Key: "err", Value: pathErr}, {Key: "name", Value: (*ptrStringer)(nil)}}
+	formatted := JSONFormatter(JSONOptions{OmitNewline: true})(LogEntry{Fields: fields})
+	if !strings.HasSuffix(string(formatted), `"err":null,"name":null}`) {
+		t.Fatal("Unexpected JSON output:", string(formatted))
+	}
+	if data, err := json.Marshal(fields); err != nil || string(data) != `{"err":null,"name":null}` {
+		t.Fatalf("Unexpected Fields encoding %s (%v)", data, err)
+	}
+}
+
+func TestReleaseBuffer(t *testing.T) {
+	// Test that large buffers, e.g. from the text formatter with large fields, are not pooled
+	large := make([]byte, 0, maxPooledBuffer+1)
+	releaseBuffer(&large)
+	textFormatter := NewTextFormatter(TextOptions{})
+	textFormatter(LogEntry{Fields: Fields{{Key: "blob", Value: string(make([]byte, maxPooledBuffer))}}})
+	for i := 0; i < 4; i++ {
+		if b := getBuffer(); cap(*b) > maxPooledBuffer {
+			t.Fatal("Expected large buffers to be dropped, got capacity:", cap(*b))
+		}
+	}
+}
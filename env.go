@@ -0,0 +1,185 @@
+package logx
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// setting is a global Logger setting read from an environment variable or bound to a flag
+type setting struct {
+	env   string                   // Environment variable, e.g. LOGX_LEVEL
+	flag  string                   // Flag name used by RegisterFlags, e.g. log-level
+	usage string                   // Flag usage
+	apply func(value string) error // Applies the value to the global Logger
+}
+
+// settings lists the global Logger settings in the order they are applied
+var settings = []setting{
+	{"LOGX_LEVEL", "log-level", "minimum log level, e.g. debug, info, warn+1", applyLevel},
+	{"LOGX_FORMAT", "log-format", "log format: text, json, logfmt or xml", applyFormat},
+	{"LOGX_TIME_FORMAT", "log-time-format", "time layout for the text and json formats, or unixmilli for json", applyTimeFormat},
+	{"LOGX_OUTPUT", "log-output", "log output: stderr, stdout or a file path to append to", applyOutput},
+	{"LOGX_COLOR", "log-color", "color mode: auto, always or never", applyColor},
+}
+
+// stdSettings holds the global Logger settings that depend on each other or own resources
+var stdSettings struct {
+	mu         sync.Mutex
+	format     string   // Format name, empty for the default text format
+	timeFormat string   // Time layout, empty for the format's default
+	file       *os.File // File opened for LOGX_OUTPUT, closed when the output changes again
+}
+
+// configureFromEnv applies the LOGX_* environment variables to the global Logger
+// Invalid values are reported on the global Logger and otherwise ignored
+func configureFromEnv() {
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.apply(value); err != nil {
+			std.Warnw("logx: ignoring invalid environment variable", "name", s.env, "error", err)
+		}
+	}
+}
+
+// RegisterFlags defines flags on fs that configure the global Logger when set,
+// -log-level, -log-format, -log-time-format, -log-output and -log-color, the
+// counterparts of the LOGX_LEVEL, LOGX_FORMAT, LOGX_TIME_FORMAT, LOGX_OUTPUT and
+// LOGX_COLOR environment variables, which they override
+// fs defaults to flag.CommandLine if nil
+func RegisterFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	for _, s := range settings {
+		fs.Var(&settingFlag{setting: s, value: os.Getenv(s.env)}, s.flag, s.usage)
+	}
+}
+
+// settingFlag is a flag.Value applying a setting to the global Logger
+type settingFlag struct {
+	setting
+	value string
+}
+
+// String implements the flag.Value interface
+func (f *settingFlag) String() string {
+	return f.value
+}
+
+// Set implements the flag.Value interface
+func (f *settingFlag) Set(value string) error {
+	// Make sure the environment is applied first so the flag overrides it
+	_std()
+	if err := f.apply(value); err != nil {
+		return err
+	}
+	f.value = value
+	return nil
+}
+
+// applyLevel sets the minimum level of the global Logger
+func applyLevel(value string) error {
+	level, err := ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	std.SetLevel(level)
+	return nil
+}
+
+// applyColor sets the color mode of the global Logger
+func applyColor(value string) error {
+	var mode ColorMode
+	if err := mode.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	std.SetColor(mode)
+	return nil
+}
+
+// applyFormat sets the format of the global Logger
+func applyFormat(value string) error {
+	stdSettings.mu.Lock()
+	defer stdSettings.mu.Unlock()
+	return setFormatter(strings.ToLower(strings.TrimSpace(value)), stdSettings.timeFormat)
+}
+
+// applyTimeFormat sets the time layout of the global Logger's format
+func applyTimeFormat(value string) error {
+	stdSettings.mu.Lock()
+	defer stdSettings.mu.Unlock()
+	return setFormatter(stdSettings.format, value)
+}
+
+// setFormatter sets the formatter for a format name and time layout on the global
+// Logger, it must be called with stdSettings.mu held
+func setFormatter(format, timeFormat string) error {
+	fn, err := newFormatter(format, timeFormat)
+	if err != nil {
+		return err
+	}
+	std.SetFormatter(fn)
+	stdSettings.format, stdSettings.timeFormat = format, timeFormat
+	return nil
+}
+
+// newFormatter returns the built-in formatter for a format name and time layout
+// An empty format selects text, an empty time layout the format's default
+func newFormatter(format, timeFormat string) (Formatter, error) {
+	switch format {
+	case "", "text":
+		if timeFormat == TimeFormatUnixMilli {
+			return nil, fmt.Errorf("logx: time format %q is only supported by the json format", timeFormat)
+		}
+		return NewTextFormatter(TextOptions{TimeLayout: timeFormat}), nil
+	case "json":
+		return JSONFormatter(JSONOptions{TimeFormat: timeFormat}), nil
+	case "logfmt", "xml":
+		// ParseLogfmt and XMLDecoder rely on the fixed RFC 3339 time
+		if timeFormat != "" {
+			return nil, fmt.Errorf("logx: the %s format does not support a time format", format)
+		}
+		if format == "xml" {
+			return XMLFormatter, nil
+		}
+		return LogfmtFormatter, nil
+	default:
+		return nil, fmt.Errorf("logx: unknown format %q", format)
+	}
+}
+
+// applyOutput sets the output of the global Logger, opening the file if value is a path
+// A file opened by an earlier call is closed once it is no longer the output
+func applyOutput(value string) error {
+	var w *os.File
+	switch value {
+	case "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		f, err := os.OpenFile(value, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		w = f
+	}
+	stdSettings.mu.Lock()
+	defer stdSettings.mu.Unlock()
+	// Wait for the writes in progress with the previous file before closing it
+	std.replaceOutput(w)
+	if stdSettings.file != nil && stdSettings.file != w {
+		_ = stdSettings.file.Close()
+	}
+	stdSettings.file = nil
+	if w != os.Stderr && w != os.Stdout {
+		stdSettings.file = w
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package logx
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// resetStdSettings resets the global Logger so the next use reads the environment again
+func resetStdSettings(t *testing.T) {
+	t.Helper()
+	std = nil
+	stdOnce = sync.Once{}
+	stdSettings.format, stdSettings.timeFormat = "", ""
+	if stdSettings.file != nil {
+		_ = stdSettings.file.Close()
+		stdSettings.file = nil
+	}
+}
+
+func TestConfigureFromEnv(t *testing.T) {
+	// Test configuring the global Logger from LOGX_* variables
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("LOGX_LEVEL", "warn")
+	t.Setenv("LOGX_FORMAT", "json")
+	t.Setenv("LOGX_TIME_FORMAT", TimeFormatUnixMilli)
+	t.Setenv("LOGX_OUTPUT", path)
+	t.Setenv("LOGX_COLOR", "never")
+	resetStdSettings(t)
+	defer resetStdSettings(t)
+
+	Info("hidden")
+	Warnw("shown", "k", 1)
+	if err := Sync(); err != nil {
+		t.Fatal("Expected no error from Sync, got:", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if strings.Contains(out, "hidden") || !strings.Contains(out, `"message":"shown"`) || !strings.Contains(out, `"k":1`) {
+		t.Fatal("Unexpected output:", out)
+	}
+	if !strings.HasPrefix(out, `{"time":1`) {
+		t.Fatal("Expected Unix millisecond timestamps, got:", out)
+	}
+	if std.core.colorMode != ColorNever {
+		t.Fatal("Expected LOGX_COLOR to set the color mode")
+	}
+}
+
+func TestConfigureFromEnvInvalid(t *testing.T) {
+	// Test that invalid variables are reported and ignored
+	t.Setenv("LOGX_LEVEL", "verbose")
+	t.Setenv("LOGX_FORMAT", "yaml")
+	resetStdSettings(t)
+	defer resetStdSettings(t)
+
+	// Create the global Logger with a captured output before reading the environment
+	var out safeWriter
+	stdOnce.Do(func() {
+		std = New(&out)
+		configureFromEnv()
+	})
+
+	if strings.Count(out.String(), "ignoring invalid environment variable") != 2 ||
+		!strings.Contains(out.String(), "LOGX_LEVEL") || !strings.Contains(out.String(), "LOGX_FORMAT") {
+		t.Fatal("Expected a warning per invalid variable, got:", out.String())
+	}
+	if GetLevel() != LevelDebug {
+		t.Fatal("Expected the default level to be kept, got:", GetLevel())
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	// Test that flags configure the global Logger and override the environment
+	t.Setenv("LOGX_LEVEL", "error")
+	resetStdSettings(t)
+	defer resetStdSettings(t)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+	if f := fs.Lookup("log-level"); f == nil || f.DefValue != "error" {
+		t.Fatal("Expected -log-level to default to LOGX_LEVEL")
+	}
+	if err := fs.Parse([]string{"-log-level=info", "-log-time-format=15:04", "-log-format=logfmt"}); err == nil {
+		t.Fatal("Expected logfmt with a time format to be rejected")
+	}
+
+	var out safeWriter
+	if err := fs.Parse([]string{"-log-level", "info", "-log-format", "text", "-log-time-format", "15:04", "-log-output", "stdout"}); err != nil {
+		t.Fatal("Expected no error from Parse, got:", err)
+	}
+	if GetLevel() != LevelInfo || std.core.writer != os.Stdout {
+		t.Fatal("Expected the flags to be applied")
+	}
+	SetOutput(&out)
+	Info("formatted")
+	if !strings.Contains(out.String(), "INFO") || strings.Contains(out.String(), "-") {
+		t.Fatal("Expected the time layout to be applied, got:", out.String())
+	}
+
+	for _, args := range [][]string{{"-log-level=verbose"}, {"-log-color=sometimes"}, {"-log-format=yaml"}, {"-log-output=" + t.TempDir()}} {
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestOutputFlagWaitsForWrites(t *testing.T) {
+	// Test that switching the output file at runtime does not fail writes in progress
+	resetStdSettings(t)
+	defer resetStdSettings(t)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	var writes int32
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				atomic.AddInt32(&writes, 1)
+				if err := Log(LevelInfo, "line"); err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+	// Switch between two files while the writers log
+	for i := 0; i < 200 || atomic.LoadInt32(&writes) < 10000 && len(errs) == 0; i++ {
+		if err := fs.Set("log-output", filepath.Join(dir, fmt.Sprintf("app%d.log", i%2))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+	select {
+	case err := <-errs:
+		t.Fatal("Expected no write errors, got:", err)
+	default:
+	}
+}
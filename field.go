@@ -0,0 +1,110 @@
+package logx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// badKey is the key used for values that are not preceded by a string key
+const badKey = "!BADKEY"
+
+// Field represents a structured key/value pair attached to a log entry
+type Field struct {
+	Key   string      // Field name
+	Value interface{} // Field value, rendered with fmt for text output and encoding/json for JSON output
+}
+
+// Fields is an ordered collection of key/value pairs
+type Fields []Field
+
+// MarshalJSON implements the json.Marshaler interface
+// Fields are encoded as a JSON object with keys in their original order
+func (fs Fields) MarshalJSON() ([]byte, error) {
+	buf := append(make([]byte, 0, 64), '{')
+	buf = appendJSONFields(buf, fs, false)
+	return append(buf, '}'), nil
+}
+
+// xmlField is the XML representation of a single Field
+type xmlField struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML implements the xml.Marshaler interface
+// Fields are encoded as <fields><field key="k">v</field></fields> with values rendered by fmt
+func (fs Fields) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, f := range fs {
+		err := e.EncodeElement(xmlField{Key: f.Key, Value: fmt.Sprint(f.Value)}, xml.StartElement{Name: xml.Name{Local: "field"}})
+		if err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface
+// Field values are decoded as strings
+func (fs *Fields) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		Fields []xmlField `xml:"field"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	for _, f := range v.Fields {
+		*fs = append(*fs, Field{Key: f.Key, Value: f.Value})
+	}
+	return nil
+}
+
+// toFields converts alternating key/value pairs into Fields
+// A Field in the list is used as is; a value without a string key is stored under "!BADKEY"
+func toFields(keysAndValues []interface{}) Fields {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make(Fields, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		switch k := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case string:
+			if i+1 >= len(keysAndValues) {
+				fields = append(fields, Field{Key: badKey, Value: k})
+				break
+			}
+			fields = append(fields, Field{Key: k, Value: keysAndValues[i+1]})
+			i++
+		default:
+			fields = append(fields, Field{Key: badKey, Value: k})
+		}
+	}
+	return fields
+}
+
+// formatFieldValue renders a field value for text output
+// Values containing spaces, quotes, '=' or control characters are quoted
+func formatFieldValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if needsQuoting(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsQuoting reports whether a text value must be quoted to stay unambiguous
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError
+	}) >= 0
+}
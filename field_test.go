@@ -0,0 +1,117 @@
+package logx
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToFields(t *testing.T) {
+	// Test converting alternating key/value pairs into fields
+	fields := toFields([]interface{}{
+		"user", 42,
+		Field{Key: "direct", Value: true},
+		"latency", time.Second,
+		7,
+		"dangling",
+	})
+
+	expected := Fields{
+		{Key: "user", Value: 42},
+		{Key: "direct", Value: true},
+		{Key: "latency", Value: time.Second},
+		{Key: badKey, Value: 7},
+		{Key: badKey, Value: "dangling"},
+	}
+
+	if len(fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %d: %v", len(expected), len(fields), fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Errorf("Field %d = %v, expected %v", i, fields[i], expected[i])
+		}
+	}
+
+	if toFields(nil) != nil {
+		t.Fatal("Expected nil fields for no key/value pairs")
+	}
+}
+
+func TestFieldsMarshalJSON(t *testing.T) {
+	// Test that fields are encoded as an ordered JSON object
+	fields := Fields{
+		{Key: "z", Value: 1},
+		{Key: "a", Value: "text"},
+		{Key: "err", Value: errors.New("boom")},
+		{Key: "nil", Value: nil},
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal("Expected no error from json.Marshal, got:", err)
+	}
+
+	expected := `{"z":1,"a":"text","err":"boom","nil":null}`
+	if string(data) != expected {
+		t.Fatalf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestLogEntryJSONFields(t *testing.T) {
+	// Test that LogEntry JSON includes fields as an object
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelInfo,
+		Message: "done",
+		Fields:  Fields{{Key: "user", Value: "bob"}},
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal("Expected no error from json.Marshal, got:", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal("Expected valid JSON, got:", err)
+	}
+	fields, ok := decoded["fields"].(map[string]interface{})
+	if !ok || fields["user"] != "bob" {
+		t.Fatalf("Expected fields object with user=bob, got %s", data)
+	}
+
+	// Entries without fields omit the key
+	entry.Fields = nil
+	data, _ = json.Marshal(entry)
+	decoded = nil
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal("Expected valid JSON, got:", err)
+	}
+	if _, ok := decoded["fields"]; ok {
+		t.Fatalf("Expected no fields key, got %s", data)
+	}
+}
+
+func TestFormatFieldValue(t *testing.T) {
+	// Test quoting of text field values
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"plain", "plain"},
+		{42, "42"},
+		{"with space", `"with space"`},
+		{"a=b", `"a=b"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"", `""`},
+	}
+
+	for _, tt := range tests {
+		if got := formatFieldValue(tt.value); got != tt.expected {
+			t.Errorf("formatFieldValue(%#v) = %s, expected %s", tt.value, got, tt.expected)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package logx
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
 	"fmt"
 	"github.com/fatih/color"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,7 +20,14 @@ type LogEntry struct {
 	File       string    `json:"file" xml:"file"`                         // File path where the log is located (relative or formatted path)
 	Line       int       `json:"line" xml:"line"`                         // Line number in the file where the log is located
 	Message    string    `json:"message" xml:"message"`                   // Log message content
+	Fields     Fields    `json:"fields,omitempty" xml:"fields,omitempty"` // Structured key/value pairs in the order they were given
 	CallerSkip int       `json:"-" xml:"-"`                               // Stack depth for determining the call source location (file and line number)
+	Color      bool      `json:"-" xml:"-"`                               // Whether the formatter may use ANSI colors, decided by the Logger from its writer
+
+	Context context.Context `json:"-" xml:"-"` // Context given to the ...Context methods, nil otherwise, for hooks and custom formatters
+
+	encoded *fieldCache // Encodings of the first cached Fields, which are the Logger's context fields
+	cached  int         // Number of leading Fields covered by encoded
 }
 
 // Formatter defines a function type for formatting log entries
@@ -23,26 +35,421 @@ type LogEntry struct {
 // Output: Formatted log string
 type Formatter func(entry LogEntry) []byte
 
-var DefaultFormatter Formatter = func(entry LogEntry) []byte {
-	// Time format
-	timestamp := entry.Time.Format("2006-01-02 15:04:05")
-	// Log level in uppercase
-	level := entry.Level.String()
+// DefaultTimeLayout is the time layout used by DefaultFormatter
+const DefaultTimeLayout = "2006-01-02 15:04:05"
+
+// DefaultFormatter is the text formatter used by New, equivalent to NewTextFormatter(TextOptions{})
+var DefaultFormatter Formatter = NewTextFormatter(TextOptions{})
 
-	fileLine := fmt.Sprintf("[%s:%d]", TrimCallerPath(entry.File, 1), entry.Line)
-	// Log prefix
-	prefix := ""
+// TextOptions configures NewTextFormatter
+// Zero values select the defaults noted on each field
+type TextOptions struct {
+	TimeLayout string         // Layout passed to time.Format, default DefaultTimeLayout (e.g. "2006-01-02 15:04:05.000000 -0700" for sub-second precision and zone)
+	Location   *time.Location // Time zone the time is printed in (e.g. time.UTC, time.Local or time.FixedZone), default nil keeps the entry's own zone, which is local time for entries created by Logger
+	Elapsed    bool           // Print the time elapsed since Start instead of the wall clock time
+	Start      time.Time      // Reference time for Elapsed, default the time NewTextFormatter is called
+}
+
+// NewTextFormatter returns a human readable text Formatter
+// Output: time LEVEL [file:line] prefix: message key=value ...
+func NewTextFormatter(opts TextOptions) Formatter {
+	layout := orDefault(opts.TimeLayout, DefaultTimeLayout)
+	start := opts.Start
+	if opts.Elapsed && start.IsZero() {
+		start = time.Now()
+	}
+	// Cached context fields are encoded with and without colors
+	plainKey, colorKey := new(byte), new(byte)
+	encodePlain, encodeColor := textFieldsEncoder(false), textFieldsEncoder(true)
+
+	return func(entry LogEntry) []byte {
+		// Time format
+		var timestamp string
+		switch {
+		case opts.Elapsed:
+			timestamp = fmt.Sprintf("%+.6fs", entry.Time.Sub(start).Seconds())
+		case opts.Location != nil:
+			timestamp = entry.Time.In(opts.Location).Format(layout)
+		default:
+			timestamp = entry.Time.Format(layout)
+		}
+		// Log level in uppercase
+		level := entry.Level.String()
+
+		fileLine := fmt.Sprintf("[%s:%d]", TrimCallerPath(entry.File, 1), entry.Line)
+		// Log prefix
+		prefix := ""
+		if entry.Prefix != "" {
+			prefix = entry.Prefix + ": "
+		}
+		// Custom default output format, colored only when the Logger allows it
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%s %s %s %s%s",
+			timestamp,
+			paint(entry.Level.Color(), level, entry.Color),
+			paint(color.New(color.FgHiBlack), fileLine, entry.Color),
+			paint(color.New(color.FgHiBlack).Add(color.Bold), prefix, entry.Color),
+			paint(entry.Level.Color(), entry.Message, entry.Color)))
+		// Structured fields as key=value after the message
+		if len(entry.Fields) > 0 {
+			b := getBuffer()
+			if entry.Color {
+				*b = appendFields(*b, entry, colorKey, encodeColor)
+			} else {
+				*b = appendFields(*b, entry, plainKey, encodePlain)
+			}
+			sb.Write(*b)
+			releaseBuffer(b)
+		}
+		sb.WriteByte('\n')
+		return []byte(sb.String())
+	}
+}
+
+// textFieldsEncoder returns a function appending fields as " key=value" text
+func textFieldsEncoder(colored bool) func(buf []byte, fields Fields) []byte {
+	return func(buf []byte, fields Fields) []byte {
+		for _, f := range fields {
+			buf = append(buf, ' ')
+			buf = append(buf, paint(color.New(color.FgCyan), f.Key, colored)...)
+			buf = append(buf, '=')
+			buf = append(buf, formatFieldValue(f.Value)...)
+		}
+		return buf
+	}
+}
+
+// logfmtKey identifies LogfmtFormatter in field caches
+var logfmtKey = new(byte)
+
+// LogfmtFormatter formats entries as logfmt, e.g.
+// time=2023-01-01T12:00:00Z level=info prefix=db caller=file.go:42 msg="query done" rows=10
+// Values containing spaces, quotes, '=' or control characters are quoted with Go escaping,
+// and fields named like an entry attribute are written as e.g. fields.msg=..., so
+// ParseLogfmt can read them back
+var LogfmtFormatter Formatter = func(entry LogEntry) []byte {
+	b := getBuffer()
+	buf := append(*b, "time="...)
+	buf = entry.Time.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, " level="...)
+	buf = appendLogfmtValue(buf, strings.ToLower(entry.Level.String()))
 	if entry.Prefix != "" {
-		prefix = entry.Prefix + ": "
-	}
-	// Custom default output format
-	logStr := fmt.Sprintf("%s %s %s %s%s",
-		timestamp,
-		entry.Level.Color().Sprint(level),
-		color.New(color.FgHiBlack).Sprint(fileLine),
-		color.New(color.FgHiBlack).Add(color.Bold).Sprint(prefix),
-		entry.Level.Color().Sprint(entry.Message))
-	return []byte(logStr + "\n")
+		buf = append(buf, " prefix="...)
+		buf = appendLogfmtValue(buf, entry.Prefix)
+	}
+	buf = append(buf, " caller="...)
+	buf = appendLogfmtValue(buf, TrimCallerPath(entry.File, 1)+":"+strconv.Itoa(entry.Line))
+	buf = append(buf, " msg="...)
+	buf = appendLogfmtValue(buf, entry.Message)
+	buf = appendFields(buf, entry, logfmtKey, appendLogfmtFields)
+	buf = append(buf, '\n')
+	*b = buf
+	return putBuffer(b)
+}
+
+// appendLogfmtFields appends fields as " key=value" pairs
+// Keys naming an entry attribute get a "fields." prefix, which ParseLogfmt removes again
+func appendLogfmtFields(buf []byte, fields Fields) []byte {
+	for _, f := range fields {
+		buf = append(buf, ' ')
+		if isEscapedKey(f.Key, logfmtReserved) {
+			buf = append(buf, escapedKeyPrefix...)
+		}
+		buf = appendLogfmtKey(buf, f.Key)
+		buf = append(buf, '=')
+		buf = appendLogfmtValue(buf, fmt.Sprint(f.Value))
+	}
+	return buf
+}
+
+// escapedKeyPrefix is prepended to field keys that would clash with entry attributes
+const escapedKeyPrefix = "fields."
+
+// logfmtReserved holds the keys of the entry attributes written by LogfmtFormatter
+var logfmtReserved = map[string]bool{"time": true, "level": true, "prefix": true, "caller": true, "msg": true}
+
+// isEscapedKey reports whether a field key gets escapedKeyPrefix: keys naming an entry
+// attribute in reserved, e.g. "msg", and keys that already look escaped, e.g. "fields.msg",
+// so that the two never produce the same key and escaped keys can be told apart
+func isEscapedKey(key string, reserved map[string]bool) bool {
+	for strings.HasPrefix(key, escapedKeyPrefix) {
+		key = key[len(escapedKeyPrefix):]
+	}
+	return reserved[key]
+}
+
+// appendLogfmtKey appends a logfmt key, replacing characters that are not allowed in keys
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	start := len(buf)
+	buf = append(buf, key...)
+	for i, c := range buf[start:] {
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			buf[start+i] = '_'
+		}
+	}
+	return buf
+}
+
+// appendLogfmtValue appends a logfmt value, quoting it when needed
+func appendLogfmtValue(buf []byte, s string) []byte {
+	if needsQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// ParseLogfmt parses a line produced by LogfmtFormatter back into a LogEntry
+// The time, level, prefix, caller and msg keys fill the corresponding LogEntry fields,
+// all other keys become Fields with string values in their original order, with the
+// "fields." prefix LogfmtFormatter adds to clashing field keys removed
+func ParseLogfmt(line []byte) (LogEntry, error) {
+	var entry LogEntry
+	s := strings.TrimRight(string(line), "\r\n")
+	for i := 0; i < len(s); {
+		// Skip separating spaces
+		if s[i] == ' ' {
+			i++
+			continue
+		}
+		// Read the key
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' {
+			if s[i] == '"' {
+				return entry, fmt.Errorf("logx: unexpected quote in key at offset %d", i)
+			}
+			i++
+		}
+		key := s[start:i]
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			if i < len(s) && s[i] == '"' {
+				// Quoted value, find the closing quote honoring escapes
+				end := i + 1
+				for end < len(s) && s[end] != '"' {
+					if s[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end >= len(s) {
+					return entry, fmt.Errorf("logx: unterminated quoted value for key %q", key)
+				}
+				v, err := strconv.Unquote(s[i : end+1])
+				if err != nil {
+					return entry, fmt.Errorf("logx: invalid quoted value for key %q: %v", key, err)
+				}
+				value = v
+				i = end + 1
+				if i < len(s) && s[i] != ' ' {
+					return entry, fmt.Errorf("logx: missing space after value for key %q", key)
+				}
+			} else {
+				start = i
+				for i < len(s) && s[i] != ' ' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		if err := setLogfmtValue(&entry, key, value); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+// setLogfmtValue stores a parsed logfmt key/value pair into entry
+func setLogfmtValue(entry *LogEntry, key, value string) error {
+	switch key {
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("logx: invalid time %q: %v", value, err)
+		}
+		entry.Time = t
+	case "level":
+		level, err := ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		entry.Level = level
+	case "prefix":
+		entry.Prefix = value
+	case "caller":
+		idx := strings.LastIndexByte(value, ':')
+		if idx == -1 {
+			entry.File = value
+			return nil
+		}
+		line, err := strconv.Atoi(value[idx+1:])
+		if err != nil {
+			return fmt.Errorf("logx: invalid caller line in %q", value)
+		}
+		entry.File, entry.Line = value[:idx], line
+	case "msg":
+		entry.Message = value
+	default:
+		if strings.HasPrefix(key, escapedKeyPrefix) && isEscapedKey(key, logfmtReserved) {
+			key = key[len(escapedKeyPrefix):]
+		}
+		entry.Fields = append(entry.Fields, Field{Key: key, Value: value})
+	}
+	return nil
+}
+
+// xmlEntryStart is the element wrapping each entry written by XMLFormatter
+var xmlEntryStart = xml.StartElement{Name: xml.Name{Local: "entry"}}
+
+// XMLFormatter formats each entry as a single <entry> element followed by a newline
+// Element names follow the xml struct tags of LogEntry, text content is escaped by encoding/xml
+var XMLFormatter Formatter = func(entry LogEntry) []byte {
+	var buf bytes.Buffer
+	// Encoding cannot fail, every value is rendered as escaped text
+	_ = xml.NewEncoder(&buf).EncodeElement(entry, xmlEntryStart)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// XMLDecoder reads a stream of entries written by XMLFormatter
+type XMLDecoder struct {
+	d *xml.Decoder
+}
+
+// NewXMLDecoder returns a decoder reading entries from r
+func NewXMLDecoder(r io.Reader) *XMLDecoder {
+	return &XMLDecoder{d: xml.NewDecoder(r)}
+}
+
+// Decode reads the next <entry> element into entry
+// It returns io.EOF when there are no more entries
+func (d *XMLDecoder) Decode(entry *LogEntry) error {
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			return err
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == xmlEntryStart.Name.Local {
+			*entry = LogEntry{}
+			return d.d.DecodeElement(entry, &se)
+		}
+	}
+}
+
+// TimeFormatUnixMilli selects Unix milliseconds as a JSON number for JSONOptions.TimeFormat
+const TimeFormatUnixMilli = "unixmilli"
+
+// JSONOptions configures JSONFormatter
+// Zero values select the defaults noted on each field
+type JSONOptions struct {
+	TimeKey     string // Key for the log time, default "time" (e.g. "ts")
+	LevelKey    string // Key for the log level, default "level"
+	MessageKey  string // Key for the log message, default "message" (e.g. "msg")
+	PrefixKey   string // Key for the log prefix, default "prefix", omitted when the prefix is empty
+	CallerKey   string // Key for the caller as a "file:line" string, default "caller"
+	FieldsKey   string // Key of a nested object holding the fields, default empty to put fields at the top level, where fields named like one of the keys above, or like such an escaped key, are written as "fields.<key>"
+	TimeFormat  string // Layout passed to time.Format or TimeFormatUnixMilli, default time.RFC3339Nano
+	CallerDepth int    // Number of path segments kept by TrimCallerPath, default 1, negative keeps the full path
+	OmitNewline bool   // Do not terminate each entry with a newline, by default output is newline-delimited JSON
+}
+
+// JSONFormatter returns a Formatter that encodes each entry as a single JSON object
+// Entries are written into a reused buffer without going through reflection, except for
+// field values of types the encoder does not know, which fall back to encoding/json
+func JSONFormatter(opts JSONOptions) Formatter {
+	timeKey := orDefault(opts.TimeKey, "time")
+	levelKey := orDefault(opts.LevelKey, "level")
+	messageKey := orDefault(opts.MessageKey, "message")
+	prefixKey := orDefault(opts.PrefixKey, "prefix")
+	callerKey := orDefault(opts.CallerKey, "caller")
+	timeFormat := orDefault(opts.TimeFormat, time.RFC3339Nano)
+	callerDepth := opts.CallerDepth
+	if callerDepth == 0 {
+		callerDepth = 1
+	}
+	fieldsKey := new(byte) // Identifies this formatter in field caches
+	encodeFields := func(buf []byte, fields Fields) []byte {
+		return appendJSONFields(buf, fields, true)
+	}
+	if opts.FieldsKey == "" {
+		// Top-level fields must not repeat the keys of the entry's own attributes, nor
+		// the keys other fields get when escaped, as with LogfmtFormatter
+		reserved := map[string]bool{timeKey: true, levelKey: true, messageKey: true, prefixKey: true, callerKey: true}
+		encodeFields = func(buf []byte, fields Fields) []byte {
+			for _, f := range fields {
+				key := f.Key
+				if isEscapedKey(key, reserved) {
+					key = escapedKeyPrefix + key
+				}
+				buf = append(buf, ',')
+				buf = appendJSONString(buf, key)
+				buf = append(buf, ':')
+				buf = appendJSONValue(buf, f.Value)
+			}
+			return buf
+		}
+	}
+
+	return func(entry LogEntry) []byte {
+		b := getBuffer()
+		buf := append(*b, '{')
+		buf = appendJSONString(buf, timeKey)
+		buf = append(buf, ':')
+		if timeFormat == TimeFormatUnixMilli {
+			buf = strconv.AppendInt(buf, entry.Time.UnixMilli(), 10)
+		} else {
+			buf = appendJSONString(buf, entry.Time.Format(timeFormat))
+		}
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, levelKey)
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, entry.Level.jsonName())
+		if entry.Prefix != "" {
+			buf = append(buf, ',')
+			buf = appendJSONString(buf, prefixKey)
+			buf = append(buf, ':')
+			buf = appendJSONString(buf, entry.Prefix)
+		}
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, callerKey)
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, TrimCallerPath(entry.File, callerDepth)+":"+strconv.Itoa(entry.Line))
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, messageKey)
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, entry.Message)
+		if len(entry.Fields) > 0 {
+			if opts.FieldsKey != "" {
+				buf = append(buf, ',')
+				buf = appendJSONString(buf, opts.FieldsKey)
+				buf = append(buf, ':', '{')
+				// Every field is written with a leading comma, drop the first one
+				start := len(buf)
+				buf = appendFields(buf, entry, fieldsKey, encodeFields)
+				buf = append(buf[:start], buf[start+1:]...)
+				buf = append(buf, '}')
+			} else {
+				buf = appendFields(buf, entry, fieldsKey, encodeFields)
+			}
+		}
+		buf = append(buf, '}')
+		if !opts.OmitNewline {
+			buf = append(buf, '\n')
+		}
+		*b = buf
+		return putBuffer(b)
+	}
+}
+
+// orDefault returns s, or def if s is empty
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
 }
 
 func TrimCallerPath(path string, n int) string {
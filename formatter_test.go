@@ -1,6 +1,9 @@
 package logx
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -118,3 +121,430 @@ func TestAllLevelsInFormatter(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultFormatterFields(t *testing.T) {
+	// Test that fields are rendered as key=value after the message
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelInfo,
+		File:    "/path/to/file.go",
+		Line:    42,
+		Message: "request done",
+		Fields: Fields{
+			{Key: "user", Value: 7},
+			{Key: "path", Value: "/a b"},
+		},
+	}
+
+	formatted := string(DefaultFormatter(entry))
+
+	if !strings.Contains(formatted, "user") || !strings.Contains(formatted, "=7") {
+		t.Fatal("Expected formatted log to contain user=7, got:", formatted)
+	}
+	if !strings.Contains(formatted, `="/a b"`) {
+		t.Fatal("Expected formatted log to contain quoted path, got:", formatted)
+	}
+	if strings.Index(formatted, "request done") > strings.Index(formatted, "user") {
+		t.Fatal("Expected fields after the message, got:", formatted)
+	}
+	if !strings.HasSuffix(formatted, "\n") {
+		t.Fatal("Expected formatted log to end with newline")
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	// Test JSON formatter with default options
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 123, time.UTC),
+		Level:   LevelWarn,
+		Prefix:  "DB",
+		File:    "/path/to/file.go",
+		Line:    42,
+		Message: "slow \"query\"\n",
+		Fields:  Fields{{Key: "rows", Value: 10}, {Key: "table", Value: "users"}},
+	}
+
+	formatted := JSONFormatter(JSONOptions{})(entry)
+
+	if formatted[len(formatted)-1] != '\n' {
+		t.Fatal("Expected JSON output to end with newline")
+	}
+
+	expected := `{"time":"2023-01-01T12:00:00.000000123Z","level":"WARN","prefix":"DB","caller":"file.go:42","message":"slow \"query\"\n","rows":10,"table":"users"}` + "\n"
+	if string(formatted) != expected {
+		t.Fatalf("Unexpected JSON output:\n%s\nexpected:\n%s", formatted, expected)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(formatted, &decoded); err != nil {
+		t.Fatal("Expected valid JSON, got:", err)
+	}
+	if decoded["message"] != entry.Message {
+		t.Fatalf("Expected message %q, got %q", entry.Message, decoded["message"])
+	}
+}
+
+func TestJSONFormatterOptions(t *testing.T) {
+	// Test JSON formatter with custom keys, time encoding and nested fields
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelInfo,
+		File:    "/path/to/file.go",
+		Line:    7,
+		Message: "hello",
+		Fields:  Fields{{Key: "k", Value: "v"}},
+	}
+
+	formatted := JSONFormatter(JSONOptions{
+		TimeKey:     "ts",
+		MessageKey:  "msg",
+		CallerKey:   "src",
+		FieldsKey:   "fields",
+		TimeFormat:  TimeFormatUnixMilli,
+		CallerDepth: 2,
+		OmitNewline: true,
+	})(entry)
+
+	expected := `{"ts":1672574400000,"level":"INFO","src":"to/file.go:7","msg":"hello","fields":{"k":"v"}}`
+	if string(formatted) != expected {
+		t.Fatalf("Unexpected JSON output:\n%s\nexpected:\n%s", formatted, expected)
+	}
+}
+
+func TestJSONFormatterKeyCollisions(t *testing.T) {
+	// Test that top-level fields never repeat the keys of the entry's attributes
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelInfo,
+		File:    "/path/to/file.go",
+		Line:    7,
+		Message: "hi",
+		Fields: Fields{{Key: "message", Value: "dup"}, {Key: "ts", Value: 1}, {Key: "prefix", Value: "p"}, {Key: "time", Value: "t"},
+			{Key: "fields.message", Value: "real"}, {Key: "fields.other", Value: "o"}},
+	}
+
+	formatted := JSONFormatter(JSONOptions{TimeKey: "ts", OmitNewline: true})(entry)
+	expected := `{"ts":"2023-01-01T12:00:00Z","level":"INFO","caller":"file.go:7","message":"hi",` +
+		`"fields.message":"dup","fields.ts":1,"fields.prefix":"p","time":"t","fields.fields.message":"real","fields.other":"o"}`
+	if string(formatted) != expected {
+		t.Fatalf("Unexpected JSON output:\n%s\nexpected:\n%s", formatted, expected)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(formatted, &decoded); err != nil || decoded["message"] != "hi" {
+		t.Fatalf("Expected the message to survive decoding, got %v (%v)", decoded, err)
+	}
+
+	// Nested fields cannot collide and keep their keys
+	formatted = JSONFormatter(JSONOptions{FieldsKey: "fields", OmitNewline: true})(entry)
+	if !strings.HasSuffix(string(formatted), `"fields":{"message":"dup","ts":1,"prefix":"p","time":"t","fields.message":"real","fields.other":"o"}}`) {
+		t.Fatal("Unexpected JSON output:", string(formatted))
+	}
+}
+
+func TestJSONFormatterOutputNotAliased(t *testing.T) {
+	// Test that returned slices do not alias the pooled buffer
+	fn := JSONFormatter(JSONOptions{})
+	first := fn(LogEntry{Message: "first"})
+	copyOfFirst := string(first)
+	for i := 0; i < 10; i++ {
+		fn(LogEntry{Message: "second"})
+	}
+	if string(first) != copyOfFirst {
+		t.Fatal("Expected earlier output to be unaffected by later calls")
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	// Test logfmt output
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelInfo,
+		Prefix:  "db",
+		File:    "/path/to/file.go",
+		Line:    42,
+		Message: "query done",
+		Fields:  Fields{{Key: "rows", Value: 10}, {Key: "bad key", Value: "x"}},
+	}
+
+	expected := `time=2023-01-01T12:00:00Z level=info prefix=db caller=file.go:42 msg="query done" rows=10 bad_key=x` + "\n"
+	if got := string(LogfmtFormatter(entry)); got != expected {
+		t.Fatalf("Unexpected logfmt output:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestLogfmtRoundTrip(t *testing.T) {
+	// Test that values survive a format/parse round trip
+	values := []string{
+		"plain",
+		"with spaces",
+		`with "quotes"`,
+		"key=value",
+		"multi\nline\r\n",
+		`back\slash`,
+		"tab\there",
+		"",
+		"unicode 日本語",
+	}
+
+	for _, v := range values {
+		entry := LogEntry{
+			Time:    time.Date(2023, 1, 1, 12, 0, 0, 123456789, time.UTC),
+			Level:   LevelWarn + 1,
+			Prefix:  v,
+			File:    "file.go",
+			Line:    7,
+			Message: v,
+			Fields:  Fields{{Key: "value", Value: v}, {Key: "n", Value: "1"}},
+		}
+
+		formatted := LogfmtFormatter(entry)
+		if strings.Count(string(formatted), "\n") != 1 {
+			t.Fatalf("Expected a single line for %q, got %q", v, formatted)
+		}
+
+		parsed, err := ParseLogfmt(formatted)
+		if err != nil {
+			t.Fatalf("ParseLogfmt(%q) returned error: %v", formatted, err)
+		}
+		if !parsed.Time.Equal(entry.Time) || parsed.Level != entry.Level || parsed.File != entry.File || parsed.Line != entry.Line {
+			t.Fatalf("Round trip of %q changed metadata: %+v", v, parsed)
+		}
+		if parsed.Message != v {
+			t.Fatalf("Round trip of message %q gave %q", v, parsed.Message)
+		}
+		// An empty prefix is omitted by the formatter
+		if parsed.Prefix != v {
+			t.Fatalf("Round trip of prefix %q gave %q", v, parsed.Prefix)
+		}
+		if len(parsed.Fields) != 2 || parsed.Fields[0] != (Field{Key: "value", Value: v}) || parsed.Fields[1] != (Field{Key: "n", Value: "1"}) {
+			t.Fatalf("Round trip of fields with %q gave %v", v, parsed.Fields)
+		}
+	}
+}
+
+func TestLogfmtReservedKeysRoundTrip(t *testing.T) {
+	// Test that fields named like entry attributes survive a format/parse round trip
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelInfo,
+		File:    "file.go",
+		Line:    7,
+		Message: "hi",
+		Fields: Fields{
+			{Key: "time", Value: "yesterday"},
+			{Key: "msg", Value: "dup"},
+			{Key: "prefix", Value: "p"},
+			{Key: "fields.level", Value: "x"},
+			{Key: "fields.other", Value: "y"},
+		},
+	}
+
+	formatted := LogfmtFormatter(entry)
+	expected := "time=2023-01-01T12:00:00Z level=info caller=file.go:7 msg=hi fields.time=yesterday " +
+		"fields.msg=dup fields.prefix=p fields.fields.level=x fields.other=y\n"
+	if string(formatted) != expected {
+		t.Fatalf("Unexpected logfmt output:\n%s\nexpected:\n%s", formatted, expected)
+	}
+
+	parsed, err := ParseLogfmt(formatted)
+	if err != nil {
+		t.Fatal("ParseLogfmt returned error:", err)
+	}
+	if parsed.Message != "hi" || parsed.Prefix != "" || !parsed.Time.Equal(entry.Time) {
+		t.Fatalf("Fields leaked into the entry: %+v", parsed)
+	}
+	if len(parsed.Fields) != len(entry.Fields) {
+		t.Fatalf("Expected fields %v, got %v", entry.Fields, parsed.Fields)
+	}
+	for i := range entry.Fields {
+		if parsed.Fields[i] != entry.Fields[i] {
+			t.Fatalf("Expected fields %v, got %v", entry.Fields, parsed.Fields)
+		}
+	}
+
+	// Through a Logger as well
+	var buffer bytes.Buffer
+	logger := New(&buffer)
+	logger.SetFormatter(LogfmtFormatter)
+	logger.Infow("hi", "time", "yesterday")
+	if parsed, err := ParseLogfmt(buffer.Bytes()); err != nil || parsed.Fields[0] != (Field{Key: "time", Value: "yesterday"}) {
+		t.Fatalf("Unexpected round trip: %+v, %v", parsed, err)
+	}
+}
+
+func TestParseLogfmtInvalid(t *testing.T) {
+	// Test rejection of malformed logfmt lines
+	inputs := []string{
+		`msg="unterminated`,
+		`msg="bad"suffix`,
+		`level=loud`,
+		`time=yesterday`,
+		`caller=file.go:x`,
+		`k"ey=v`,
+	}
+
+	for _, in := range inputs {
+		if _, err := ParseLogfmt([]byte(in)); err == nil {
+			t.Errorf("Expected ParseLogfmt(%q) to fail", in)
+		}
+	}
+}
+
+func TestXMLFormatter(t *testing.T) {
+	// Test XML output with escaping
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelError,
+		Prefix:  "audit",
+		File:    "/path/to/file.go",
+		Line:    42,
+		Message: `<script>alert("x") & more</script>`,
+		Fields:  Fields{{Key: "user", Value: "a&b"}},
+	}
+
+	formatted := string(XMLFormatter(entry))
+
+	if !strings.HasPrefix(formatted, "<entry>") || !strings.HasSuffix(formatted, "</entry>\n") {
+		t.Fatal("Expected a single <entry> element followed by newline, got:", formatted)
+	}
+	if strings.Count(formatted, "\n") != 1 {
+		t.Fatal("Expected a single line, got:", formatted)
+	}
+	if !strings.Contains(formatted, "<level>ERROR</level>") {
+		t.Fatal("Expected level by name, got:", formatted)
+	}
+	if strings.Contains(formatted, "<script>") {
+		t.Fatal("Expected message content to be escaped, got:", formatted)
+	}
+	if !strings.Contains(formatted, `<field key="user">a&amp;b</field>`) {
+		t.Fatal("Expected escaped field, got:", formatted)
+	}
+	if strings.Contains(formatted, "CallerSkip") {
+		t.Fatal("Expected CallerSkip to be omitted, got:", formatted)
+	}
+}
+
+func TestXMLDecoder(t *testing.T) {
+	// Test streaming entries back from XML output
+	entries := []LogEntry{
+		{
+			Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+			Level:   LevelInfo,
+			File:    "a.go",
+			Line:    1,
+			Message: "first\nline & <b>",
+		},
+		{
+			Time:    time.Date(2023, 1, 1, 12, 0, 1, 500, time.UTC),
+			Level:   LevelWarn + 1,
+			Prefix:  "db",
+			File:    "b.go",
+			Line:    2,
+			Message: "second",
+			Fields:  Fields{{Key: "rows", Value: "10"}, {Key: "q", Value: `"x"`}},
+		},
+	}
+
+	var stream bytes.Buffer
+	for _, entry := range entries {
+		stream.Write(XMLFormatter(entry))
+	}
+
+	decoder := NewXMLDecoder(&stream)
+	for i, expected := range entries {
+		var got LogEntry
+		if err := decoder.Decode(&got); err != nil {
+			t.Fatalf("Decode entry %d returned error: %v", i, err)
+		}
+		if !got.Time.Equal(expected.Time) || got.Level != expected.Level || got.Prefix != expected.Prefix ||
+			got.File != expected.File || got.Line != expected.Line || got.Message != expected.Message {
+			t.Fatalf("Entry %d = %+v, expected %+v", i, got, expected)
+		}
+		if len(got.Fields) != len(expected.Fields) {
+			t.Fatalf("Entry %d fields = %v, expected %v", i, got.Fields, expected.Fields)
+		}
+		for j := range expected.Fields {
+			if got.Fields[j] != expected.Fields[j] {
+				t.Fatalf("Entry %d fields = %v, expected %v", i, got.Fields, expected.Fields)
+			}
+		}
+	}
+
+	var extra LogEntry
+	if err := decoder.Decode(&extra); err != io.EOF {
+		t.Fatalf("Expected io.EOF after last entry, got %v", err)
+	}
+}
+
+func TestDefaultFormatterColor(t *testing.T) {
+	// Test that colors depend only on the entry, not on global color state
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelWarn,
+		File:    "/path/to/file.go",
+		Line:    42,
+		Message: "test message",
+	}
+
+	if formatted := string(DefaultFormatter(entry)); strings.Contains(formatted, "\x1b[") {
+		t.Fatalf("Expected no ANSI escapes without Color, got %q", formatted)
+	}
+
+	entry.Color = true
+	if formatted := string(DefaultFormatter(entry)); !strings.Contains(formatted, "\x1b[") {
+		t.Fatalf("Expected ANSI escapes with Color, got %q", formatted)
+	}
+}
+
+func TestTextFormatterOptions(t *testing.T) {
+	// Test configurable time layout and time zone
+	entry := LogEntry{
+		Time:    time.Date(2023, 1, 1, 12, 0, 0, 123456000, time.UTC),
+		Level:   LevelInfo,
+		File:    "/path/to/file.go",
+		Line:    42,
+		Message: "test message",
+	}
+
+	formatted := string(NewTextFormatter(TextOptions{
+		TimeLayout: "2006-01-02T15:04:05.000000Z07:00",
+		Location:   time.FixedZone("UTC+8", 8*60*60),
+	})(entry))
+	if !strings.HasPrefix(formatted, "2023-01-01T20:00:00.123456+08:00 ") {
+		t.Fatal("Expected time in fixed zone with microseconds, got:", formatted)
+	}
+
+	other := entry
+	other.Time = time.Date(2023, 1, 1, 12, 0, 0, 5e6, time.FixedZone("X", 3600))
+	formatted = string(NewTextFormatter(TextOptions{
+		TimeLayout: "15:04:05.000 MST",
+		Location:   time.UTC,
+	})(other))
+	if !strings.HasPrefix(formatted, "11:00:00.005 UTC ") {
+		t.Fatal("Expected time converted to UTC, got:", formatted)
+	}
+
+	// Without options the output matches DefaultFormatter
+	if string(NewTextFormatter(TextOptions{})(entry)) != string(DefaultFormatter(entry)) {
+		t.Fatal("Expected zero options to match DefaultFormatter")
+	}
+}
+
+func TestTextFormatterElapsed(t *testing.T) {
+	// Test relative timestamps
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	fn := NewTextFormatter(TextOptions{Elapsed: true, Start: start})
+
+	entry := LogEntry{
+		Time:    start.Add(1500 * time.Millisecond),
+		Level:   LevelInfo,
+		File:    "file.go",
+		Line:    1,
+		Message: "later",
+	}
+
+	formatted := string(fn(entry))
+	if !strings.HasPrefix(formatted, "+1.500000s ") {
+		t.Fatal("Expected elapsed time since start, got:", formatted)
+	}
+}
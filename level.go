@@ -1,22 +1,37 @@
 package logx
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/fatih/color"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
 )
 
 type Level int
 
 const (
+	LevelTrace Level = -8 // Trace level, excluded by the default minimum level
 	LevelDebug Level = -4 // Debug level
 	LevelInfo  Level = 0  // Information level
 	LevelWarn  Level = 4  // Warning level
 	LevelError Level = 8  // Error level
+	LevelPanic Level = 12 // Panic level, the Logger panics after writing the entry
+	LevelFatal Level = 16 // Fatal level, the Logger syncs and exits after writing the entry
 )
 
 // String returns the string representation of the log level
 // For non-standard levels, it appends the offset to the base level, e.g., DEBUG+1
+// Registered levels use their registered name
 func (l Level) String() string {
+	if def, ok := lookupLevel(l); ok {
+		return def.Name
+	}
 	str := func(base string, val Level) string {
 		if val == 0 {
 			return base
@@ -25,29 +40,134 @@ func (l Level) String() string {
 	}
 
 	switch {
+	case l < LevelDebug:
+		return str("TRACE", l-LevelTrace)
 	case l < LevelInfo:
 		return str("DEBUG", l-LevelDebug)
 	case l < LevelWarn:
 		return str("INFO", l-LevelInfo)
 	case l < LevelError:
 		return str("WARN", l-LevelWarn)
-	default:
+	case l < LevelPanic:
 		return str("ERROR", l-LevelError)
+	case l < LevelFatal:
+		return str("PANIC", l-LevelPanic)
+	default:
+		return str("FATAL", l-LevelFatal)
 	}
 }
 
 // MarshalJSON implements the json.Marshaler interface
 // This method is called when LogEntry is serialized with json.Marshal
 // The purpose is to serialize Level type to corresponding string (e.g., "INFO", "ERROR") instead of integer
+// Registered levels use their JSON name
 func (l Level) MarshalJSON() ([]byte, error) {
-	// Call l.String() to get the string corresponding to Level
-	// Then add double quotes and return byte slice to conform to JSON string format
-	return []byte(`"` + l.String() + `"`), nil
+	// Add double quotes and return byte slice to conform to JSON string format
+	return []byte(`"` + l.jsonName() + `"`), nil
+}
+
+// jsonName returns the name used for the level in JSON output
+func (l Level) jsonName() string {
+	if def, ok := lookupLevel(l); ok && def.JSONName != "" {
+		return def.JSONName
+	}
+	return l.String()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+// It accepts the string form produced by MarshalJSON (e.g., "INFO", "warn", "DEBUG+1")
+// as well as a bare integer value, null leaves the level unchanged
+func (l *Level) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("logx: invalid level %s", data)
+		}
+		*l = Level(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return l.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (l *Level) UnmarshalText(data []byte) error {
+	level, err := ParseLevel(string(data))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// MarshalXML implements the xml.Marshaler interface
+// The level is encoded by name (e.g., <level>INFO</level>) instead of as an integer
+func (l Level) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(l.String(), start)
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface
+func (l *Level) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	return l.UnmarshalText([]byte(s))
+}
+
+// Set implements the flag.Value interface so a Level can be bound to a command line flag
+func (l *Level) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+// ParseLevel parses a level name as produced by Level.String()
+// Names are case-insensitive and may carry an offset, e.g., "warn", "INFO+2", "Debug-1"
+// The names and JSON names of registered levels are accepted as well
+func ParseLevel(s string) (Level, error) {
+	name := strings.TrimSpace(s)
+	offset := 0
+	if i := strings.IndexAny(name, "+-"); i >= 0 {
+		n, err := strconv.Atoi(name[i:])
+		if err != nil {
+			return 0, fmt.Errorf("logx: invalid level offset in %q", s)
+		}
+		name, offset = name[:i], n
+	}
+
+	upper := strings.ToUpper(name)
+	base, ok := builtinLevels[upper]
+	if !ok {
+		if base, ok = levels().byName[upper]; !ok {
+			return 0, fmt.Errorf("logx: unknown level name %q", s)
+		}
+	}
+	return base + Level(offset), nil
 }
 
 // Color returns the color output corresponding to the log level (using github.com/fatih/color)
+// Registered levels without a color use the color of the nearest built-in level below
 func (l Level) Color() *color.Color {
+	if def, ok := lookupLevel(l); ok && def.Color != nil {
+		// Return a copy, callers may change it, e.g. to force colors on
+		c := *def.Color
+		return &c
+	}
 	switch {
+	case l >= LevelFatal: // 16 and above
+		return color.New(color.FgHiWhite, color.BgRed, color.Bold)
+	case l >= LevelPanic: // 12 and above
+		return color.New(color.FgHiMagenta, color.Bold)
 	case l >= LevelError: // 8 and above
 		return color.New(color.FgHiRed, color.Bold)
 	case l >= LevelWarn: // 4 and above
@@ -56,7 +176,118 @@ func (l Level) Color() *color.Color {
 		return color.New(color.FgGreen)
 	case l >= LevelDebug: // -4 and above
 		return color.New(color.FgBlue)
+	case l >= LevelTrace: // -8 and above
+		return color.New(color.FgCyan)
 	default:
 		return color.New(color.FgWhite)
 	}
 }
+
+// LevelDef describes a level registered with RegisterLevel
+type LevelDef struct {
+	Name     string       // Name returned by String and accepted by ParseLevel, e.g. "NOTICE"
+	JSONName string       // Name written by MarshalJSON and the JSON formatter, defaults to Name
+	Color    *color.Color // Color returned by Color, defaults to the color of the nearest built-in level below
+}
+
+// levelTable is an immutable snapshot of the registered levels
+type levelTable struct {
+	byLevel map[Level]LevelDef
+	byName  map[string]Level // Upper-case names and JSON names
+}
+
+var (
+	levelMu          sync.Mutex   // Serializes RegisterLevel
+	registeredLevels atomic.Value // Current *levelTable, replaced as a whole so lookups need no lock
+)
+
+// levels returns the current registered levels
+func levels() *levelTable {
+	if t, ok := registeredLevels.Load().(*levelTable); ok {
+		return t
+	}
+	return &levelTable{}
+}
+
+// lookupLevel returns the definition of a registered level
+func lookupLevel(l Level) (LevelDef, bool) {
+	def, ok := levels().byLevel[l]
+	return def, ok
+}
+
+// builtinLevels maps the names of the built-in levels to their values
+var builtinLevels = map[string]Level{
+	"TRACE": LevelTrace,
+	"DEBUG": LevelDebug,
+	"INFO":  LevelInfo,
+	"WARN":  LevelWarn,
+	"ERROR": LevelError,
+	"PANIC": LevelPanic,
+	"FATAL": LevelFatal,
+}
+
+// RegisterLevel gives a level its own name, JSON name and color, e.g.
+//
+//	logx.RegisterLevel(logx.LevelInfo+2, logx.LevelDef{Name: "NOTICE", Color: color.New(color.FgCyan)})
+//
+// Names may contain letters, digits, '_' and '.', and are matched case-insensitively
+// by ParseLevel, so they must not be used by another level
+// Registering a level again replaces its definition, registering a built-in level renames it
+// It is safe to call concurrently with logging, but is meant to be called during initialization
+func RegisterLevel(level Level, def LevelDef) error {
+	if err := validLevelName(def.Name); err != nil {
+		return err
+	}
+	if def.JSONName != "" {
+		if err := validLevelName(def.JSONName); err != nil {
+			return err
+		}
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	old := levels()
+	t := &levelTable{
+		byLevel: make(map[Level]LevelDef, len(old.byLevel)+1),
+		byName:  make(map[string]Level, len(old.byName)+2),
+	}
+	for l, d := range old.byLevel {
+		if l != level {
+			t.byLevel[l] = d
+		}
+	}
+	for name, l := range old.byName {
+		if l != level {
+			t.byName[name] = l
+		}
+	}
+	for _, name := range []string{def.Name, def.JSONName} {
+		if name == "" {
+			continue
+		}
+		upper := strings.ToUpper(name)
+		if l, ok := builtinLevels[upper]; ok && l != level {
+			return fmt.Errorf("logx: level name %q is used by %d", name, l)
+		}
+		if l, ok := t.byName[upper]; ok && l != level {
+			return fmt.Errorf("logx: level name %q is used by %d", name, l)
+		}
+		t.byName[upper] = level
+	}
+	t.byLevel[level] = def
+	registeredLevels.Store(t)
+	return nil
+}
+
+// validLevelName reports an error if name cannot be parsed back by ParseLevel or written unquoted
+func validLevelName(name string) error {
+	if name == "" {
+		return errors.New("logx: empty level name")
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+			return fmt.Errorf("logx: invalid character %q in level name %q", r, name)
+		}
+	}
+	return nil
+}
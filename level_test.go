@@ -0,0 +1,285 @@
+package logx
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"github.com/fatih/color"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLevelString(t *testing.T) {
+	// Test string representation of standard and offset levels
+	tests := []struct {
+		level    Level
+		expected string
+	}{
+		{LevelTrace, "TRACE"},
+		{LevelDebug, "DEBUG"},
+		{LevelInfo, "INFO"},
+		{LevelWarn, "WARN"},
+		{LevelError, "ERROR"},
+		{LevelPanic, "PANIC"},
+		{LevelFatal, "FATAL"},
+		{LevelDebug - 1, "TRACE+3"},
+		{LevelTrace - 2, "TRACE-2"},
+		{LevelInfo + 2, "INFO+2"},
+		{LevelWarn - 1, "INFO+3"},
+		{LevelError + 2, "ERROR+2"},
+		{LevelPanic - 1, "ERROR+3"},
+		{LevelFatal + 10, "FATAL+10"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.level.String(); got != tt.expected {
+			t.Errorf("Level(%d).String() = %q, expected %q", int(tt.level), got, tt.expected)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	// Test parsing level names
+	tests := []struct {
+		input    string
+		expected Level
+	}{
+		{"trace", LevelTrace},
+		{"DEBUG", LevelDebug},
+		{"debug", LevelDebug},
+		{"Info", LevelInfo},
+		{"WARN", LevelWarn},
+		{"error", LevelError},
+		{"INFO+2", LevelInfo + 2},
+		{"warn-1", LevelWarn - 1},
+		{" ERROR+3 ", LevelError + 3},
+		{"panic", LevelPanic},
+		{"Fatal+1", LevelFatal + 1},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseLevel(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseLevelInvalid(t *testing.T) {
+	// Test parsing invalid level names
+	for _, input := range []string{"", "VERBOSE", "INFO+", "INFO+x", "+1", "WARN++1"} {
+		if _, err := ParseLevel(input); err == nil {
+			t.Errorf("Expected ParseLevel(%q) to fail", input)
+		}
+	}
+}
+
+func TestLevelRoundTrip(t *testing.T) {
+	// Test String -> ParseLevel round trip for every offset
+	for l := LevelTrace - 10; l <= LevelFatal+10; l++ {
+		got, err := ParseLevel(l.String())
+		if err != nil {
+			t.Fatalf("ParseLevel(%q) returned error: %v", l.String(), err)
+		}
+		if got != l {
+			t.Fatalf("Round trip of %d through %q gave %d", int(l), l.String(), int(got))
+		}
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+	// Test JSON round trip
+	type config struct {
+		Level Level `json:"level"`
+	}
+
+	data, err := json.Marshal(config{Level: LevelWarn + 1})
+	if err != nil {
+		t.Fatal("Expected no error from json.Marshal, got:", err)
+	}
+	if string(data) != `{"level":"WARN+1"}` {
+		t.Fatalf("Unexpected JSON: %s", data)
+	}
+
+	var c config
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal("Expected no error from json.Unmarshal, got:", err)
+	}
+	if c.Level != LevelWarn+1 {
+		t.Fatalf("Expected WARN+1, got %v", c.Level)
+	}
+
+	if err := json.Unmarshal([]byte(`{"level":"debug"}`), &c); err != nil || c.Level != LevelDebug {
+		t.Fatalf("Expected DEBUG, got %v (err=%v)", c.Level, err)
+	}
+	if err := json.Unmarshal([]byte(`{"level":8}`), &c); err != nil || c.Level != LevelError {
+		t.Fatalf("Expected ERROR from integer, got %v (err=%v)", c.Level, err)
+	}
+	if err := json.Unmarshal([]byte(`{"level":null}`), &c); err != nil || c.Level != LevelError {
+		t.Fatalf("Expected null to keep ERROR, got %v (err=%v)", c.Level, err)
+	}
+	if err := json.Unmarshal([]byte(`{"level":"loud"}`), &c); err == nil {
+		t.Fatal("Expected error for unknown level name")
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	// Test text round trip
+	text, err := (LevelInfo - 2).MarshalText()
+	if err != nil {
+		t.Fatal("Expected no error from MarshalText, got:", err)
+	}
+
+	var l Level
+	if err := l.UnmarshalText(text); err != nil {
+		t.Fatal("Expected no error from UnmarshalText, got:", err)
+	}
+	if l != LevelInfo-2 {
+		t.Fatalf("Expected %v, got %v", LevelInfo-2, l)
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	// Test binding a Level to a command line flag
+	level := LevelInfo
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&level, "level", "log level")
+
+	if err := fs.Parse([]string{"-level", "warn"}); err != nil {
+		t.Fatal("Expected no error from flag parsing, got:", err)
+	}
+	if level != LevelWarn {
+		t.Fatalf("Expected WARN, got %v", level)
+	}
+}
+
+func TestLevelXML(t *testing.T) {
+	// Test XML round trip
+	type config struct {
+		Level Level `xml:"level"`
+	}
+
+	data, err := xml.Marshal(config{Level: LevelError - 1})
+	if err != nil {
+		t.Fatal("Expected no error from xml.Marshal, got:", err)
+	}
+	if string(data) != "<config><level>WARN+3</level></config>" {
+		t.Fatalf("Unexpected XML: %s", data)
+	}
+
+	var c config
+	if err := xml.Unmarshal(data, &c); err != nil {
+		t.Fatal("Expected no error from xml.Unmarshal, got:", err)
+	}
+	if c.Level != LevelError-1 {
+		t.Fatalf("Expected WARN+3, got %v", c.Level)
+	}
+
+	if err := xml.Unmarshal([]byte("<config><level>loud</level></config>"), &c); err == nil {
+		t.Fatal("Expected error for unknown level name")
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	// Test that registered levels are used by String, Color, JSON and parsing
+	defer registeredLevels.Store(&levelTable{})
+	notice := LevelInfo + 2
+	if err := RegisterLevel(notice, LevelDef{Name: "NOTICE", JSONName: "notice", Color: color.New(color.FgCyan)}); err != nil {
+		t.Fatal("Expected no error from RegisterLevel, got:", err)
+	}
+	audit := LevelWarn + 1
+	if err := RegisterLevel(audit, LevelDef{Name: "AUDIT"}); err != nil {
+		t.Fatal("Expected no error from RegisterLevel, got:", err)
+	}
+
+	if notice.String() != "NOTICE" || audit.String() != "AUDIT" || (notice+1).String() != "INFO+3" {
+		t.Fatalf("Unexpected names %s, %s, %s", notice, audit, notice+1)
+	}
+	data, _ := json.Marshal([]Level{notice, audit})
+	if string(data) != `["notice","AUDIT"]` {
+		t.Fatalf("Unexpected JSON: %s", data)
+	}
+	var decoded []Level
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded[0] != notice || decoded[1] != audit {
+		t.Fatalf("Expected JSON names to parse back, got %v, %v", decoded, err)
+	}
+	for input, expected := range map[string]Level{"notice": notice, "Audit+1": audit + 1, "NOTICE-2": LevelInfo} {
+		if got, err := ParseLevel(input); err != nil || got != expected {
+			t.Errorf("ParseLevel(%q) = %v, %v, expected %v", input, got, err, expected)
+		}
+	}
+
+	if !notice.Color().Equals(color.New(color.FgCyan)) {
+		t.Fatal("Expected the registered color")
+	}
+	if !audit.Color().Equals(LevelWarn.Color()) {
+		t.Fatal("Expected the color of the built-in level below")
+	}
+
+	// The JSON formatter uses the JSON name
+	entry := JSONFormatter(JSONOptions{})(LogEntry{Level: notice, Message: "m"})
+	if !strings.Contains(string(entry), `"level":"notice"`) {
+		t.Fatal("Expected the JSON name in JSON output, got:", string(entry))
+	}
+
+	// Registering again replaces the definition and frees the old name
+	if err := RegisterLevel(audit, LevelDef{Name: "SECURITY"}); err != nil {
+		t.Fatal("Expected no error re-registering a level, got:", err)
+	}
+	if _, err := ParseLevel("AUDIT"); err == nil || audit.String() != "SECURITY" {
+		t.Fatal("Expected the old name to be gone")
+	}
+}
+
+func TestRegisterLevelInvalid(t *testing.T) {
+	// Test rejecting names that could not be parsed back or are taken
+	defer registeredLevels.Store(&levelTable{})
+	_ = RegisterLevel(LevelInfo+1, LevelDef{Name: "NOTICE"})
+
+	tests := []struct {
+		level Level
+		def   LevelDef
+	}{
+		{LevelInfo + 2, LevelDef{}},
+		{LevelInfo + 2, LevelDef{Name: "NOT ICE"}},
+		{LevelInfo + 2, LevelDef{Name: "A+1"}},
+		{LevelInfo + 2, LevelDef{Name: "OK", JSONName: `"x"`}},
+		{LevelInfo + 2, LevelDef{Name: "warn"}},
+		{LevelInfo + 2, LevelDef{Name: "notice"}},
+		{LevelInfo + 2, LevelDef{Name: "OK", JSONName: "Notice"}},
+	}
+	for _, tt := range tests {
+		if err := RegisterLevel(tt.level, tt.def); err == nil {
+			t.Errorf("Expected RegisterLevel(%d, %+v) to fail", tt.level, tt.def)
+		}
+	}
+	if _, err := ParseLevel("OK"); err == nil {
+		t.Fatal("Expected failed registrations to leave no trace")
+	}
+}
+
+func TestRegisterLevelConcurrent(t *testing.T) {
+	// Test registering while other goroutines format levels, run with -race
+	defer registeredLevels.Store(&levelTable{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_ = (LevelInfo + 1).String()
+				_ = (LevelInfo + 1).Color()
+				_, _ = ParseLevel("NOTICE")
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		_ = RegisterLevel(LevelInfo+1, LevelDef{Name: "NOTICE", Color: color.New(color.FgCyan)})
+	}
+	wg.Wait()
+}
@@ -1,9 +1,12 @@
 package logx
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os"
 	"sync"
+	"time"
 )
 
 var (
@@ -12,17 +15,25 @@ var (
 )
 
 // _std returns the global Logger instance (singleton pattern)
-// Initializes Logger on first call and sets callDepth
+// Initializes Logger on first call, configured from the LOGX_* environment variables
+// The package-level functions call the unexported log methods directly, so the
+// global Logger uses the same callerSkip as any other Logger and loggers derived
+// from it report the correct file and line number
 func _std() *Logger {
 	stdOnce.Do(func() {
 		// Create a Logger that outputs to standard error
-		_log := New(os.Stderr)
-		_log.callerSkip = 3
-		std = _log
+		std = New(os.Stderr)
+		configureFromEnv()
 	})
 	return std
 }
 
+// Default returns the global Logger instance
+// It can be used to derive child loggers, e.g. logx.Default().With("service", "api")
+func Default() *Logger {
+	return _std()
+}
+
 // SetOutput sets the output destination for the global Logger (thread-safe)
 func SetOutput(w io.Writer) {
 	_std().SetOutput(w)
@@ -38,28 +49,178 @@ func SetFormatter(fn Formatter) {
 	_std().SetFormatter(fn)
 }
 
+// SetColor sets how colors are decided for the global Logger (thread-safe)
+func SetColor(mode ColorMode) {
+	_std().SetColor(mode)
+}
+
+// SetLevel sets the minimum level to output for the global Logger (thread-safe)
+func SetLevel(level Level) {
+	_std().SetLevel(level)
+}
+
+// GetLevel returns the minimum level to output for the global Logger (thread-safe)
+func GetLevel() Level {
+	return _std().GetLevel()
+}
+
+// SetVModule sets per-module minimum levels for the global Logger (thread-safe)
+// e.g. SetVModule("db=debug,http/*=warn,payments.go=trace")
+func SetVModule(spec string) error {
+	return _std().SetVModule(spec)
+}
+
+// ApplyConfig replaces the settings of the global Logger with those described by cfg (thread-safe)
+// An invalid config leaves the global Logger unchanged
+func ApplyConfig(cfg Config) error {
+	return _std().ApplyConfig(cfg)
+}
+
+// WatchConfig applies the config file at path to the global Logger and applies it
+// again whenever it changes, polling every interval
+func WatchConfig(path string, interval time.Duration, onError func(err error)) (stop func(), err error) {
+	return _std().WatchConfig(path, interval, onError)
+}
+
+// SetExitFunc sets the function Fatal calls to end the process for the global Logger (thread-safe)
+func SetExitFunc(fn func(code int)) {
+	_std().SetExitFunc(fn)
+}
+
+// SetPanicFunc sets the function Panic calls with the message for the global Logger (thread-safe)
+func SetPanicFunc(fn func(msg string)) {
+	_std().SetPanicFunc(fn)
+}
+
+// Sync writes out data buffered by the writers of the global Logger
+func Sync() error {
+	return _std().Sync()
+}
+
+// Close flushes and closes the writers of the global Logger, except the standard output streams
+func Close() error {
+	return _std().Close()
+}
+
+// Trace logs at Trace level
+func Trace(format string, v ...interface{}) {
+	_ = _std().log(LevelTrace, format, v...)
+}
+
 // Debug logs at Debug level
 func Debug(format string, v ...interface{}) {
-	_std().Debug(format, v...)
+	_ = _std().log(LevelDebug, format, v...)
 }
 
 // Info logs at Info level
 func Info(format string, v ...interface{}) {
-	_std().Info(format, v...)
+	_ = _std().log(LevelInfo, format, v...)
 }
 
 // Warn logs at Warn level
 func Warn(format string, v ...interface{}) {
-	_std().Warn(format, v...)
+	_ = _std().log(LevelWarn, format, v...)
 }
 
 // Error logs at Error level
 func Error(format string, v ...interface{}) {
-	_std().Error(format, v...)
+	_ = _std().log(LevelError, format, v...)
+}
+
+// Panic logs at Panic level with the operands formatted as by fmt.Sprint, then panics with the message
+func Panic(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	_ = _std().logw(LevelPanic, msg, nil)
+	_std().panic(msg)
+}
+
+// Panicf logs at Panic level with printf-style formatting, then panics with the message
+func Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	_ = _std().logw(LevelPanic, msg, nil)
+	_std().panic(msg)
+}
+
+// Fatal logs at Fatal level with the operands formatted as by fmt.Sprint, then syncs and exits with status 1
+func Fatal(v ...interface{}) {
+	_ = _std().logw(LevelFatal, fmt.Sprint(v...), nil)
+	_std().exit()
+}
+
+// Fatalf logs at Fatal level with printf-style formatting, then syncs and exits with status 1
+func Fatalf(format string, v ...interface{}) {
+	_ = _std().log(LevelFatal, format, v...)
+	_std().exit()
 }
 
 // Log logs at the specified Level
-// Logs will be output if the level is higher than the Logger's minimum level
+// Logs will be output if the level is not lower than the Logger's minimum level
 func Log(level Level, format string, v ...interface{}) error {
-	return _std().Log(level, format, v...)
+	return _std().log(level, format, v...)
+}
+
+// Tracew logs at Trace level with alternating key/value pairs
+func Tracew(msg string, keysAndValues ...interface{}) {
+	_ = _std().logw(LevelTrace, msg, keysAndValues)
+}
+
+// Debugw logs at Debug level with alternating key/value pairs
+func Debugw(msg string, keysAndValues ...interface{}) {
+	_ = _std().logw(LevelDebug, msg, keysAndValues)
+}
+
+// Infow logs at Info level with alternating key/value pairs
+func Infow(msg string, keysAndValues ...interface{}) {
+	_ = _std().logw(LevelInfo, msg, keysAndValues)
+}
+
+// Warnw logs at Warn level with alternating key/value pairs
+func Warnw(msg string, keysAndValues ...interface{}) {
+	_ = _std().logw(LevelWarn, msg, keysAndValues)
+}
+
+// Errorw logs at Error level with alternating key/value pairs
+func Errorw(msg string, keysAndValues ...interface{}) {
+	_ = _std().logw(LevelError, msg, keysAndValues)
+}
+
+// Logw logs at the specified Level with alternating key/value pairs
+func Logw(level Level, msg string, keysAndValues ...interface{}) error {
+	return _std().logw(level, msg, keysAndValues)
+}
+
+// TraceContext logs at Trace level with the Logger stored in ctx, or the global
+// Logger, adding the fields extracted from ctx
+func TraceContext(ctx context.Context, format string, v ...interface{}) {
+	_ = FromContext(ctx).logContext(ctx, LevelTrace, format, v...)
+}
+
+// DebugContext logs at Debug level with the Logger stored in ctx, or the global
+// Logger, adding the fields extracted from ctx
+func DebugContext(ctx context.Context, format string, v ...interface{}) {
+	_ = FromContext(ctx).logContext(ctx, LevelDebug, format, v...)
+}
+
+// InfoContext logs at Info level with the Logger stored in ctx, or the global
+// Logger, adding the fields extracted from ctx
+func InfoContext(ctx context.Context, format string, v ...interface{}) {
+	_ = FromContext(ctx).logContext(ctx, LevelInfo, format, v...)
+}
+
+// WarnContext logs at Warn level with the Logger stored in ctx, or the global
+// Logger, adding the fields extracted from ctx
+func WarnContext(ctx context.Context, format string, v ...interface{}) {
+	_ = FromContext(ctx).logContext(ctx, LevelWarn, format, v...)
+}
+
+// ErrorContext logs at Error level with the Logger stored in ctx, or the global
+// Logger, adding the fields extracted from ctx
+func ErrorContext(ctx context.Context, format string, v ...interface{}) {
+	_ = FromContext(ctx).logContext(ctx, LevelError, format, v...)
+}
+
+// LogContext logs at the specified Level with the Logger stored in ctx, or the
+// global Logger, adding the fields extracted from ctx
+func LogContext(ctx context.Context, level Level, format string, v ...interface{}) error {
+	return FromContext(ctx).logContext(ctx, level, format, v...)
 }
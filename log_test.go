@@ -169,3 +169,119 @@ func TestGlobalLogFunction(t *testing.T) {
 		t.Fatal("Expected message from Log function, got:", output)
 	}
 }
+
+func TestGlobalSetLevel(t *testing.T) {
+	// Test setting global minimum level
+	buffer := &bytes.Buffer{}
+
+	// Reset global logger
+	std = nil
+	stdOnce = sync.Once{}
+
+	SetOutput(buffer)
+	SetLevel(LevelError)
+
+	if GetLevel() != LevelError {
+		t.Fatalf("Expected global level ERROR, got %v", GetLevel())
+	}
+
+	Info("filtered message")
+	if buffer.Len() != 0 {
+		t.Fatal("Expected INFO to be discarded, got:", buffer.String())
+	}
+
+	Error("error message")
+	if !strings.Contains(buffer.String(), "error message") {
+		t.Fatal("Expected ERROR message in output, got:", buffer.String())
+	}
+}
+
+func TestGlobalStructuredLogging(t *testing.T) {
+	// Test global logging functions that take key/value pairs
+	var capturedEntry LogEntry
+
+	// Reset global logger
+	std = nil
+	stdOnce = sync.Once{}
+
+	SetFormatter(func(entry LogEntry) []byte {
+		capturedEntry = entry
+		return nil
+	})
+
+	Errorw("failed", "attempt", 3)
+
+	if capturedEntry.Message != "failed" || capturedEntry.Level != LevelError {
+		t.Fatalf("Unexpected entry %+v", capturedEntry)
+	}
+	if len(capturedEntry.Fields) != 1 || capturedEntry.Fields[0] != (Field{Key: "attempt", Value: 3}) {
+		t.Fatalf("Unexpected fields %v", capturedEntry.Fields)
+	}
+	if !strings.HasSuffix(capturedEntry.File, "log_test.go") {
+		t.Fatalf("Expected caller in log_test.go, got %s", capturedEntry.File)
+	}
+}
+
+func TestDefaultWith(t *testing.T) {
+	// Test deriving a child from the global logger
+	var capturedEntry LogEntry
+
+	// Reset global logger
+	std = nil
+	stdOnce = sync.Once{}
+
+	SetFormatter(func(entry LogEntry) []byte {
+		capturedEntry = entry
+		return nil
+	})
+
+	child := Default().With("service", "api")
+	child.Info("child message")
+
+	if len(capturedEntry.Fields) != 1 || capturedEntry.Fields[0] != (Field{Key: "service", Value: "api"}) {
+		t.Fatalf("Unexpected fields %v", capturedEntry.Fields)
+	}
+	if !strings.HasSuffix(capturedEntry.File, "log_test.go") {
+		t.Fatalf("Expected caller in log_test.go, got %s", capturedEntry.File)
+	}
+
+	// Package-level functions report the caller too
+	Info("global message")
+	if !strings.HasSuffix(capturedEntry.File, "log_test.go") {
+		t.Fatalf("Expected caller in log_test.go, got %s", capturedEntry.File)
+	}
+}
+
+func TestGlobalFatalAndPanic(t *testing.T) {
+	// Test the package-level Fatal and Panic functions with injected exit and panic
+	var capturedEntry LogEntry
+
+	// Reset global logger
+	std = nil
+	stdOnce = sync.Once{}
+
+	SetFormatter(func(entry LogEntry) []byte {
+		capturedEntry = entry
+		return nil
+	})
+	var code int
+	var panicked string
+	SetExitFunc(func(c int) { code = c })
+	SetPanicFunc(func(msg string) { panicked = msg })
+
+	Fatalf("shutting down: %s", "disk full")
+	if code != 1 || capturedEntry.Level != LevelFatal || capturedEntry.Message != "shutting down: disk full" {
+		t.Fatalf("Unexpected exit %d with entry %+v", code, capturedEntry)
+	}
+	if !strings.HasSuffix(capturedEntry.File, "log_test.go") {
+		t.Fatalf("Expected caller in log_test.go, got %s", capturedEntry.File)
+	}
+
+	Panic("invariant ", "broken")
+	if panicked != "invariant broken" || capturedEntry.Level != LevelPanic {
+		t.Fatalf("Unexpected panic %q with entry %+v", panicked, capturedEntry)
+	}
+	if !strings.HasSuffix(capturedEntry.File, "log_test.go") {
+		t.Fatalf("Expected caller in log_test.go, got %s", capturedEntry.File)
+	}
+}
@@ -1,11 +1,13 @@
 package logx
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,36 +15,156 @@ type ILogger interface {
 	SetOutput(w io.Writer)
 	SetPrefix(prefix string)
 	SetFormatter(fn Formatter)
+	SetColor(mode ColorMode)
+	SetLevel(level Level)
+	GetLevel() Level
+	SetVModule(spec string) error
+	SetExitFunc(fn func(code int))
+	SetPanicFunc(fn func(msg string))
+	Sync() error
+	Close() error
+	Trace(format string, v ...interface{})
 	Debug(format string, v ...interface{})
 	Info(format string, v ...interface{})
 	Warn(format string, v ...interface{})
 	Error(format string, v ...interface{})
+	Panic(v ...interface{})
+	Panicf(format string, v ...interface{})
+	Fatal(v ...interface{})
+	Fatalf(format string, v ...interface{})
 	Log(level Level, format string, v ...interface{}) error
+	Tracew(msg string, keysAndValues ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	Logw(level Level, msg string, keysAndValues ...interface{}) error
+	TraceContext(ctx context.Context, format string, v ...interface{})
+	DebugContext(ctx context.Context, format string, v ...interface{})
+	InfoContext(ctx context.Context, format string, v ...interface{})
+	WarnContext(ctx context.Context, format string, v ...interface{})
+	ErrorContext(ctx context.Context, format string, v ...interface{})
+	LogContext(ctx context.Context, level Level, format string, v ...interface{}) error
 }
 
 // New creates a new Logger instance
 // Parameter w specifies the log output destination (can be os.Stdout, os.Stderr, file, etc.)
 func New(w io.Writer) *Logger {
-	l := &Logger{}
+	l := &Logger{core: &core{inflight: new(sync.WaitGroup), now: time.Now}}
 	l.SetOutput(w)
 	l.SetFormatter(DefaultFormatter) // Use default formatter function
+	l.SetLevel(LevelDebug)           // Output everything but Trace by default
 	return l
 }
 
 // Logger represents a logging object
+// Loggers derived with With or WithPrefix share the output state (writer, formatter and level)
+// of their parent, while the prefix and context fields belong to each Logger
 type Logger struct {
 	mu         sync.RWMutex // Read-write lock for concurrent safety
-	writer     io.Writer    // Log output destination
+	core       *core        // Output state shared with derived loggers
 	prefix     string       // Log prefix
-	formatter  Formatter    // Log formatting function
+	fields     Fields       // Context fields attached to every entry, converted once by With
+	encoded    *fieldCache  // Encodings of fields by the built-in formatters, made once per formatter
 	callerSkip int          // runtime.Caller level offset for correctly displaying call file and line number
 }
 
+// core holds the output state shared by a Logger and all loggers derived from it
+type core struct {
+	mu        sync.RWMutex     // Read-write lock for concurrent safety
+	writer    io.Writer        // Log output destination
+	formatter Formatter        // Log formatting function
+	level     int32            // Minimum level to output, accessed atomically so the check stays lock-free
+	colorMode ColorMode        // How colors are decided
+	color     bool             // Whether entries may be colored, decided from colorMode and writer
+	sinks     []*Sink          // Additional outputs, replaced rather than modified so readers need no lock
+	exitFn    func(int)        // Called by Fatal after syncing, nil means os.Exit
+	panicFn   func(string)     // Called by Panic with the message, nil means the builtin panic
+	vmodule   atomic.Value     // Current *vmodule, nil when no rules are set
+	now       func() time.Time // Clock for entry times
+	onError   func(error)      // Called with errors writing entries, nil to only return them
+	hooks     []Hook           // Run on every entry before formatting, replaced rather than modified
+
+	inflight *sync.WaitGroup // Writes in progress with the current writers, replaced by ApplyConfig to wait for them
+	closers  []io.Closer     // Writers opened by ApplyConfig, closed when the config is replaced
+
+	parent     *core  // Core of the parent of a named Logger, nil for loggers created with New
+	own        uint8  // Output settings set on a named Logger, the others are its parent's
+	colorCache uint64 // Color decision of a named Logger with writer and mode from different cores, outputGen<<1 | color, accessed atomically
+}
+
+// With returns a derived Logger that adds the given alternating key/value pairs to every entry
+// The pairs are converted to Fields once here, and the built-in formatters encode them
+// once per formatter on first use rather than on every call
+// Values are therefore rendered when first written, later changes to values such as
+// pointers or fmt.Stringer results do not show up in the output of the built-in formatters
+// The derived Logger shares the writer, formatter and level of l
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	child := l.clone()
+	if fields := toFields(keysAndValues); len(fields) > 0 {
+		// Full slice expression forces a copy so siblings never share a backing array
+		child.fields = append(child.fields[:len(child.fields):len(child.fields)], fields...)
+		child.encoded = new(fieldCache)
+	}
+	return child
+}
+
+// WithPrefix returns a derived Logger with the given prefix
+// The derived Logger shares the writer, formatter and level of l, and setting its prefix does not affect l
+func (l *Logger) WithPrefix(prefix string) *Logger {
+	child := l.clone()
+	child.prefix = prefix
+	return child
+}
+
+// clone returns a shallow copy of l sharing the same core
+func (l *Logger) clone() *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return &Logger{
+		core:       l.core,
+		prefix:     l.prefix,
+		fields:     l.fields,
+		encoded:    l.encoded,
+		callerSkip: l.callerSkip,
+	}
+}
+
 // SetOutput sets the log output destination (thread-safe)
+// The change is visible to all loggers sharing the same output state
 func (l *Logger) SetOutput(w io.Writer) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.writer = w
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.own |= ownWriter
+	l.core.writer = w
+	l.core.color = useColor(l.core.colorMode, w)
+	outputChanged()
+}
+
+// replaceOutput sets the output destination like SetOutput, and returns once the
+// writes in progress with the previous one have finished, so it can be closed
+func (l *Logger) replaceOutput(w io.Writer) {
+	l.core.mu.Lock()
+	l.core.own |= ownWriter
+	l.core.writer = w
+	l.core.color = useColor(l.core.colorMode, w)
+	outputChanged()
+	inflight := l.core.inflight
+	l.core.inflight = new(sync.WaitGroup)
+	l.core.mu.Unlock()
+	inflight.Wait()
+}
+
+// SetColor sets how colors are decided (thread-safe)
+// With ColorAuto, colors are used only when the writer is a terminal, honoring NO_COLOR and FORCE_COLOR
+// The change is visible to all loggers sharing the same output state
+func (l *Logger) SetColor(mode ColorMode) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.own |= ownColor
+	l.core.colorMode = mode
+	l.core.color = useColor(mode, l.core.writer)
+	outputChanged()
 }
 
 // SetPrefix sets the log prefix (thread-safe)
@@ -53,10 +175,116 @@ func (l *Logger) SetPrefix(prefix string) {
 }
 
 // SetFormatter sets the log formatting function (thread-safe)
+// The change is visible to all loggers sharing the same output state
 func (l *Logger) SetFormatter(fn Formatter) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.formatter = fn
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.own |= ownFormatter
+	l.core.formatter = fn
+}
+
+// SetLevel sets the minimum level to output (thread-safe)
+// Logs below this level are discarded before any formatting takes place
+// The change is visible to all loggers sharing the same output state, and to the
+// named descendants that inherit the level, see Named
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.core.level, int32(level))
+}
+
+// GetLevel returns the minimum level to output (thread-safe)
+// A named Logger without its own level returns the level it inherits
+func (l *Logger) GetLevel() Level {
+	return l.core.getLevel()
+}
+
+// SetExitFunc sets the function Fatal calls to end the process, os.Exit by default (thread-safe)
+// Tests can replace it to cover code paths that end in Fatal
+// The change is visible to all loggers sharing the same output state
+func (l *Logger) SetExitFunc(fn func(code int)) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.own |= ownExit
+	l.core.exitFn = fn
+}
+
+// SetPanicFunc sets the function Panic calls with the message, the builtin panic by default (thread-safe)
+// The change is visible to all loggers sharing the same output state
+func (l *Logger) SetPanicFunc(fn func(msg string)) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.own |= ownPanic
+	l.core.panicFn = fn
+}
+
+// Enabled reports whether logs at the given level would be output
+// Rules set with SetVModule are not considered, as they depend on the caller
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.GetLevel()
+}
+
+// Sync writes out data buffered by the writer and sinks and commits it to stable storage
+// It uses the Flush and Sync methods of writers that have them, e.g. bufio.Writer,
+// AsyncWriter, *os.File and rotate.RotatingFile, the standard output streams are left alone
+// A named Logger only syncs the writer and sinks set on it, not those it inherits
+func (l *Logger) Sync() error {
+	return syncWriters(l.writers(true))
+}
+
+// Close flushes and closes the writer and sinks that implement io.Closer
+// The standard output streams are never closed
+// The change is visible to all loggers sharing the same output state, later writes
+// fail with the error of the closed writer
+// A named Logger only closes the writer and sinks set on it, those it inherits stay open
+func (l *Logger) Close() error {
+	var errs []error
+	for _, w := range l.writers(true) {
+		errs = append(errs, flushUnderlying(w), closeUnderlying(w))
+	}
+	return joinErrors(errs...)
+}
+
+// syncWriters syncs each of writers
+func syncWriters(writers []io.Writer) error {
+	var errs []error
+	for _, w := range writers {
+		errs = append(errs, syncUnderlying(w))
+	}
+	return joinErrors(errs...)
+}
+
+// writers returns the writer and the writers of all sinks, with owned only those set on
+// l rather than inherited by a named Logger, and otherwise all those entries are written to
+func (l *Logger) writers(owned bool) []io.Writer {
+	var writer io.Writer
+	var sinks []*Sink
+	if owned {
+		c := l.core
+		c.mu.RLock()
+		if c.parent == nil || c.own&ownWriter != 0 {
+			writer = c.writer
+		}
+		sinks = c.sinks
+		c.mu.RUnlock()
+	} else {
+		out := l.core.resolve()
+		out.done()
+		writer, sinks = out.writer, out.sinks
+	}
+	writers := make([]io.Writer, 0, len(sinks)+1)
+	if writer != nil {
+		writers = append(writers, writer)
+	}
+	for _, s := range sinks {
+		if s.writer != nil {
+			writers = append(writers, s.writer)
+		}
+	}
+	return writers
+}
+
+// Trace outputs Trace level logs
+func (l *Logger) Trace(format string, v ...interface{}) {
+	_ = l.log(LevelTrace, format, v...)
 }
 
 // Debug outputs Debug level logs
@@ -79,45 +307,177 @@ func (l *Logger) Error(format string, v ...interface{}) {
 	_ = l.log(LevelError, format, v...)
 }
 
+// Panic outputs Panic level logs with the operands formatted as by fmt.Sprint, then panics with the message
+// It panics even if the Panic level is disabled
+func (l *Logger) Panic(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	_ = l.logw(LevelPanic, msg, nil)
+	l.panic(msg)
+}
+
+// Panicf outputs printf-style Panic level logs, then panics with the message
+// It panics even if the Panic level is disabled
+func (l *Logger) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	_ = l.logw(LevelPanic, msg, nil)
+	l.panic(msg)
+}
+
+// Fatal outputs Fatal level logs with the operands formatted as by fmt.Sprint,
+// then syncs the writers and exits with status 1
+// It exits even if the Fatal level is disabled
+func (l *Logger) Fatal(v ...interface{}) {
+	_ = l.logw(LevelFatal, fmt.Sprint(v...), nil)
+	l.exit()
+}
+
+// Fatalf outputs printf-style Fatal level logs, then syncs the writers and exits with status 1
+// It exits even if the Fatal level is disabled
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	_ = l.log(LevelFatal, format, v...)
+	l.exit()
+}
+
+// Log outputs logs at the specified level
+// Logs will be output if the level is not lower than the Logger's minimum level
 func (l *Logger) Log(level Level, format string, v ...interface{}) error {
 	return l.log(level, format, v...)
 }
 
-// Log outputs logs at the specified level
+// Tracew outputs Trace level logs with alternating key/value pairs
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	_ = l.logw(LevelTrace, msg, keysAndValues)
+}
+
+// Debugw outputs Debug level logs with alternating key/value pairs
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	_ = l.logw(LevelDebug, msg, keysAndValues)
+}
+
+// Infow outputs Info level logs with alternating key/value pairs
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	_ = l.logw(LevelInfo, msg, keysAndValues)
+}
+
+// Warnw outputs Warn level logs with alternating key/value pairs
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	_ = l.logw(LevelWarn, msg, keysAndValues)
+}
+
+// Errorw outputs Error level logs with alternating key/value pairs
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	_ = l.logw(LevelError, msg, keysAndValues)
+}
+
+// Logw outputs logs at the specified level with alternating key/value pairs
+// e.g. Logw(LevelInfo, "request done", "user", id, "latency", d)
+func (l *Logger) Logw(level Level, msg string, keysAndValues ...interface{}) error {
+	return l.logw(level, msg, keysAndValues)
+}
+
+// panic calls the panic function with msg
+func (l *Logger) panic(msg string) {
+	c := l.core.lookup(ownPanic)
+	c.mu.RLock()
+	fn := c.panicFn
+	c.mu.RUnlock()
+	if fn == nil {
+		panic(msg)
+	}
+	fn(msg)
+}
+
+// exit syncs the writers so the last entries are not lost, then calls the exit function
+// Unlike Sync, a named Logger also syncs the writers it inherits
+func (l *Logger) exit() {
+	_ = syncWriters(l.writers(false))
+	c := l.core.lookup(ownExit)
+	c.mu.RLock()
+	fn := c.exitFn
+	c.mu.RUnlock()
+	if fn == nil {
+		fn = os.Exit
+	}
+	fn(1)
+}
+
+// log outputs printf-style logs at the specified level
+// It returns early if the level is disabled, before any formatting or caller lookup
+func (l *Logger) log(level Level, format string, v ...interface{}) error {
+	// Skip disabled levels as cheaply as possible
+	if !l.enabled(level) {
+		return nil
+	}
+	return l.output(nil, level, fmt.Sprintf(format, v...), nil)
+}
+
+// logw outputs logs with structured fields at the specified level
+// It returns early if the level is disabled, before converting the key/value pairs
+func (l *Logger) logw(level Level, msg string, keysAndValues []interface{}) error {
+	if !l.enabled(level) {
+		return nil
+	}
+	return l.output(nil, level, msg, toFields(keysAndValues))
+}
+
+// output writes a log entry, it must be called directly by log, logw or logContext
 // 1. Get call file and line number based on callDepth
 // 2. Format log entry using Formatter
 // 3. Write to log output destination (writer), default to os.Stdout if writer is nil
-func (l *Logger) log(level Level, format string, v ...interface{}) error {
+func (l *Logger) output(ctx context.Context, level Level, msg string, fields Fields) error {
 	// Read Logger current state with concurrent safety
 	l.mu.RLock()
 	prefix := l.prefix
-	formatter := l.formatter
-	writer := l.writer
 	callerSkip := l.callerSkip
 	if callerSkip == 0 {
 		callerSkip = 2
 	}
+	cached, encoded := len(l.fields), l.encoded
+	if len(fields) == 0 {
+		// Full slice expression so hooks appending fields cannot write into l.fields
+		fields = l.fields[:cached:cached]
+	} else if cached > 0 {
+		fields = append(l.fields[:cached:cached], fields...)
+	}
 	l.mu.RUnlock()
-	// Format log content
-	msg := fmt.Sprintf(format, v...)
-	// Get call file and line number
-	_, file, line, ok := runtime.Caller(callerSkip)
+	out := l.core.resolve()
+	defer out.done()
+	formatter, writer, colored, sinks := out.formatter, out.writer, out.color, out.sinks
+	now, onError, hooks := out.now, out.onError, out.hooks
+	// Get call file and line number, skipping output itself
+	_, file, line, ok := runtime.Caller(callerSkip + 1)
 	if !ok {
 		file = "???" // Placeholder when unable to obtain
 		line = 0
 	}
-	// Output log, default to stdout if writer is nil
-	if writer == nil {
-		writer = os.Stdout
-	}
-	_, err := writer.Write(formatter(LogEntry{
-		Time:       time.Now(),
+	entry := LogEntry{
+		Time:       now(),
 		Level:      level,
 		Prefix:     prefix,
 		CallerSkip: callerSkip,
 		File:       file,
 		Line:       line,
 		Message:    msg,
-	}))
+		Fields:     fields,
+		Color:      colored,
+		Context:    ctx,
+	}
+	if len(hooks) == 0 {
+		// Hooks may change the fields, the cached encodings are used only without them
+		entry.encoded, entry.cached = encoded, cached
+	}
+	for _, hook := range hooks {
+		hook(&entry)
+	}
+	// Output log, default to stdout if writer is nil
+	data := formatter(entry)
+	err := writeLevel(writer, level, data)
+	if len(sinks) > 0 {
+		// Additional sinks reuse the primary rendering when they share its formatter
+		err = joinErrors(err, fanOut(entry, sinks, []formatted{{key: formatterKey(formatter), color: colored, data: data}}))
+	}
+	if err != nil && onError != nil {
+		onError(err)
+	}
 	return err
 }
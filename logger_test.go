@@ -2,7 +2,11 @@ package logx
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -197,3 +201,430 @@ func TestCallerSkip(t *testing.T) {
 		t.Fatal("Expected different file/line information with different callerSkip values")
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	// Test minimum level filtering
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+
+	if logger.GetLevel() != LevelDebug {
+		t.Fatalf("Expected default level DEBUG, got %v", logger.GetLevel())
+	}
+
+	logger.SetLevel(LevelWarn)
+	if logger.GetLevel() != LevelWarn {
+		t.Fatalf("Expected level WARN, got %v", logger.GetLevel())
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	if buffer.Len() != 0 {
+		t.Fatal("Expected messages below WARN to be discarded, got:", buffer.String())
+	}
+
+	logger.Warn("warn message")
+	logger.Error("error message")
+	output := buffer.String()
+	if !strings.Contains(output, "warn message") || !strings.Contains(output, "error message") {
+		t.Fatal("Expected WARN and ERROR messages in output, got:", output)
+	}
+
+	// Custom levels are compared by value
+	buffer.Reset()
+	if err := logger.Log(LevelWarn-1, "custom below"); err != nil {
+		t.Fatal("Expected no error from Log, got:", err)
+	}
+	if buffer.Len() != 0 {
+		t.Fatal("Expected WARN-1 to be discarded, got:", buffer.String())
+	}
+}
+
+func TestDisabledLevelSkipsFormatter(t *testing.T) {
+	// Test that disabled levels never reach the formatter
+	called := 0
+	logger := New(&bytes.Buffer{})
+	logger.SetFormatter(func(entry LogEntry) []byte {
+		called++
+		return nil
+	})
+	logger.SetLevel(LevelError)
+
+	logger.Debug("debug %d", 1)
+	logger.Info("info %d", 2)
+	logger.Warn("warn %d", 3)
+	if called != 0 {
+		t.Fatalf("Expected formatter not to be called, got %d calls", called)
+	}
+
+	logger.Error("error %d", 4)
+	if called != 1 {
+		t.Fatalf("Expected formatter to be called once, got %d calls", called)
+	}
+}
+
+func TestStructuredLogging(t *testing.T) {
+	// Test logging methods that take key/value pairs
+	var capturedEntry LogEntry
+	logger := New(&bytes.Buffer{})
+	logger.SetFormatter(func(entry LogEntry) []byte {
+		capturedEntry = entry
+		return nil
+	})
+
+	logger.Infow("request done", "user", 42, "latency", "3ms")
+
+	if capturedEntry.Message != "request done" {
+		t.Fatalf("Expected message 'request done', got '%s'", capturedEntry.Message)
+	}
+	if len(capturedEntry.Fields) != 2 {
+		t.Fatalf("Expected 2 fields, got %v", capturedEntry.Fields)
+	}
+	if capturedEntry.Fields[0] != (Field{Key: "user", Value: 42}) || capturedEntry.Fields[1] != (Field{Key: "latency", Value: "3ms"}) {
+		t.Fatalf("Unexpected fields %v", capturedEntry.Fields)
+	}
+	if !strings.HasSuffix(capturedEntry.File, "logger_test.go") {
+		t.Fatalf("Expected caller in logger_test.go, got %s", capturedEntry.File)
+	}
+
+	// Messages are not treated as format strings
+	logger.Warnw("100% done")
+	if capturedEntry.Message != "100% done" || capturedEntry.Level != LevelWarn {
+		t.Fatalf("Unexpected entry %+v", capturedEntry)
+	}
+
+	// Disabled levels are skipped
+	logger.SetLevel(LevelError)
+	capturedEntry = LogEntry{}
+	logger.Debugw("hidden", "k", "v")
+	if capturedEntry.Message != "" {
+		t.Fatal("Expected Debugw to be discarded")
+	}
+	if err := logger.Logw(LevelError, "shown", "k", "v"); err != nil {
+		t.Fatal("Expected no error from Logw, got:", err)
+	}
+	if capturedEntry.Message != "shown" {
+		t.Fatal("Expected Logw at ERROR to be output")
+	}
+}
+
+func TestWith(t *testing.T) {
+	// Test derived loggers carrying context fields
+	var entries []LogEntry
+	parent := New(&bytes.Buffer{})
+	parent.SetFormatter(func(entry LogEntry) []byte {
+		entries = append(entries, entry)
+		return nil
+	})
+
+	child := parent.With("request", "r1")
+	a := child.With("user", "alice")
+	b := child.With("user", "bob")
+
+	a.Infow("from a", "step", 1)
+	b.Info("from b")
+	parent.Info("from parent")
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	expectedA := Fields{{Key: "request", Value: "r1"}, {Key: "user", Value: "alice"}, {Key: "step", Value: 1}}
+	if len(entries[0].Fields) != len(expectedA) {
+		t.Fatalf("Expected fields %v, got %v", expectedA, entries[0].Fields)
+	}
+	for i := range expectedA {
+		if entries[0].Fields[i] != expectedA[i] {
+			t.Fatalf("Expected fields %v, got %v", expectedA, entries[0].Fields)
+		}
+	}
+
+	// Siblings must not see each other's fields
+	if len(entries[1].Fields) != 2 || entries[1].Fields[1] != (Field{Key: "user", Value: "bob"}) {
+		t.Fatalf("Unexpected sibling fields %v", entries[1].Fields)
+	}
+
+	// The parent is unaffected
+	if len(entries[2].Fields) != 0 {
+		t.Fatalf("Expected no fields on parent, got %v", entries[2].Fields)
+	}
+
+	// Caller information points at the call site, not at With
+	if !strings.HasSuffix(entries[0].File, "logger_test.go") || !strings.HasSuffix(entries[1].File, "logger_test.go") {
+		t.Fatalf("Expected caller in logger_test.go, got %s and %s", entries[0].File, entries[1].File)
+	}
+}
+
+// countingValue counts how often it is rendered
+type countingValue struct{ n *int32 }
+
+func (v countingValue) String() string {
+	atomic.AddInt32(v.n, 1)
+	return "counted"
+}
+
+func TestWithEncodesFieldsOnce(t *testing.T) {
+	// Test that the built-in formatters encode the context fields of a child once per formatter
+	// for caching, after writing the first entry without the cache
+	for name, formatter := range map[string]Formatter{
+		"text":   DefaultFormatter,
+		"logfmt": LogfmtFormatter,
+		"json":   JSONFormatter(JSONOptions{}),
+		"nested": JSONFormatter(JSONOptions{FieldsKey: "fields"}),
+	} {
+		var n int32
+		var buffer bytes.Buffer
+		logger := New(&buffer)
+		logger.SetFormatter(formatter)
+		child := logger.With("v", countingValue{&n}, "request", "r1")
+		child.Info("one")
+		child.Infow("two", "step", 2)
+		child.WithPrefix("db").Info("three")
+		child.Info("four")
+		if n != 2 {
+			t.Errorf("%s: expected the fields to be encoded twice, got %d", name, n)
+		}
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		if len(lines) != 4 || !strings.Contains(lines[1], "counted") || !strings.Contains(lines[1], "step") {
+			t.Errorf("%s: unexpected output %q", name, buffer.String())
+		}
+		if name == "nested" && !strings.Contains(lines[1], `"fields":{"v":"counted","request":"r1","step":2}`) {
+			t.Errorf("%s: unexpected output %q", name, lines[1])
+		}
+	}
+
+	// Hooks may change the fields, so they are encoded on every call then
+	var n int32
+	logger := New(&bytes.Buffer{})
+	logger.AddHook(func(entry *LogEntry) {})
+	child := logger.With("v", countingValue{&n})
+	child.Info("one")
+	child.Info("two")
+	child.Info("three")
+	if n != 3 {
+		t.Fatal("Expected the fields to be encoded on every call with hooks, got:", n)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	// Test derived loggers with their own prefix
+	buffer := &bytes.Buffer{}
+	parent := New(buffer)
+	parent.SetPrefix("APP")
+
+	child := parent.WithPrefix("DB")
+	child.Info("child message")
+	if !strings.Contains(buffer.String(), "DB:") {
+		t.Fatal("Expected child prefix 'DB:', got:", buffer.String())
+	}
+
+	// Changing the child's prefix does not affect the parent
+	child.SetPrefix("CACHE")
+	buffer.Reset()
+	parent.Info("parent message")
+	if !strings.Contains(buffer.String(), "APP:") {
+		t.Fatal("Expected parent prefix 'APP:', got:", buffer.String())
+	}
+}
+
+func TestWithSharesOutputState(t *testing.T) {
+	// Test that derived loggers share writer, formatter and level with their parent
+	buffer1 := &bytes.Buffer{}
+	buffer2 := &bytes.Buffer{}
+	parent := New(buffer1)
+	child := parent.With("k", "v")
+
+	parent.SetOutput(buffer2)
+	parent.SetLevel(LevelWarn)
+
+	child.Info("filtered")
+	child.Warn("shared output")
+
+	if buffer1.Len() != 0 {
+		t.Fatal("Expected nothing in the old writer, got:", buffer1.String())
+	}
+	if strings.Contains(buffer2.String(), "filtered") {
+		t.Fatal("Expected child to honor the parent's level, got:", buffer2.String())
+	}
+	if !strings.Contains(buffer2.String(), "shared output") {
+		t.Fatal("Expected child to write to the parent's new writer, got:", buffer2.String())
+	}
+}
+
+// lifecycleWriter records Flush, Sync and Close calls
+type lifecycleWriter struct {
+	bytes.Buffer
+	mu    sync.Mutex
+	calls []string
+}
+
+func (w *lifecycleWriter) Flush() error { w.record("flush"); return nil }
+func (w *lifecycleWriter) Sync() error  { w.record("sync"); return nil }
+func (w *lifecycleWriter) Close() error { w.record("close"); return nil }
+
+func (w *lifecycleWriter) record(call string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls = append(w.calls, call)
+}
+
+// snapshot returns the calls so far, for tests calling from several goroutines
+func (w *lifecycleWriter) snapshot() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.calls...)
+}
+
+// count returns how often call was made
+func (w *lifecycleWriter) count(call string) int {
+	n := 0
+	for _, c := range w.snapshot() {
+		if c == call {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSyncAndClose(t *testing.T) {
+	// Test that Sync and Close reach the writer and every sink
+	primary, sink := &lifecycleWriter{}, &lifecycleWriter{}
+	logger := New(primary)
+	logger.AddSink(NewSink(sink, nil, LevelDebug))
+
+	if err := logger.Sync(); err != nil {
+		t.Fatal("Expected no error from Sync, got:", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal("Expected no error from Close, got:", err)
+	}
+	for _, w := range []*lifecycleWriter{primary, sink} {
+		if strings.Join(w.calls, ",") != "flush,sync,flush,close" {
+			t.Fatal("Unexpected calls:", w.calls)
+		}
+	}
+
+	// Writes after Close fail with the writer's own error
+	file, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger = New(file)
+	if err := logger.Close(); err != nil {
+		t.Fatal("Expected no error closing the file, got:", err)
+	}
+	if err := logger.Log(LevelInfo, "late"); err == nil {
+		t.Fatal("Expected an error writing to a closed file")
+	}
+
+	// The standard output streams are neither synced nor closed
+	logger = New(os.Stderr)
+	if err := logger.Sync(); err != nil {
+		t.Fatal("Expected no error syncing stderr, got:", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal("Expected no error closing stderr, got:", err)
+	}
+	if _, err := os.Stderr.Stat(); err != nil {
+		t.Fatal("Expected stderr to stay open, got:", err)
+	}
+}
+
+func TestSyncAsyncWriter(t *testing.T) {
+	// Test that Sync waits for entries queued in an AsyncWriter
+	w := newGateWriter()
+	a := NewAsyncWriter(w, AsyncOptions{})
+	logger := New(a)
+	logger.SetFormatter(func(entry LogEntry) []byte { return []byte(entry.Message) })
+	logger.Info("queued")
+
+	close(w.gate)
+	if err := logger.Sync(); err != nil {
+		t.Fatal("Expected no error from Sync, got:", err)
+	}
+	if w.String() != "queued" {
+		t.Fatal("Expected the queued entry to be written by Sync, got:", w.String())
+	}
+	_ = logger.Close()
+	if !w.closed {
+		t.Fatal("Expected Close to close the AsyncWriter and its writer")
+	}
+}
+
+func TestFatal(t *testing.T) {
+	// Test that Fatal writes and syncs the entry before exiting
+	w := &lifecycleWriter{}
+	logger := New(w)
+	logger.SetFormatter(func(entry LogEntry) []byte {
+		return []byte(entry.Level.String() + " " + entry.Message + " " + filepath.Base(entry.File) + "\n")
+	})
+	var codes []int
+	logger.SetExitFunc(func(code int) {
+		// The entry must be written and flushed by the time the process exits
+		if !strings.Contains(w.String(), "FATAL") || len(w.calls) == 0 {
+			t.Error("Expected the entry to be written and synced before exiting")
+		}
+		codes = append(codes, code)
+	})
+
+	logger.Fatal("out of ", 3, " retries")
+	logger.Fatalf("code %d", 7)
+	if len(codes) != 2 || codes[0] != 1 || codes[1] != 1 {
+		t.Fatal("Expected two exits with status 1, got:", codes)
+	}
+	if w.String() != "FATAL out of 3 retries logger_test.go\nFATAL code 7 logger_test.go\n" {
+		t.Fatal("Unexpected output:", w.String())
+	}
+
+	// Fatal exits even if its level is disabled
+	logger.SetLevel(LevelFatal + 1)
+	logger.Fatal("hidden")
+	if len(codes) != 3 || strings.Contains(w.String(), "hidden") {
+		t.Fatal("Expected a silent exit, got:", codes, w.String())
+	}
+}
+
+func TestPanic(t *testing.T) {
+	// Test that Panic writes the entry, then panics with the message
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetFormatter(func(entry LogEntry) []byte { return []byte(entry.Level.String() + " " + entry.Message + "\n") })
+
+	defer func() {
+		if r := recover(); r != "bad state 1" {
+			t.Fatal("Expected a panic with the message, got:", r)
+		}
+		if buffer.String() != "PANIC bad state 1\n" {
+			t.Fatal("Unexpected output:", buffer.String())
+		}
+
+		// The panic function is replaceable
+		var msgs []string
+		logger.SetPanicFunc(func(msg string) { msgs = append(msgs, msg) })
+		logger.Panicf("value %q", "x")
+		if len(msgs) != 1 || msgs[0] != `value "x"` {
+			t.Fatal("Expected the injected panic function to receive the message, got:", msgs)
+		}
+	}()
+	logger.Panic("bad state ", 1)
+	t.Fatal("Expected Panic not to return")
+}
+
+func TestTrace(t *testing.T) {
+	// Test that Trace is disabled by default and has its own name once enabled
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetFormatter(func(entry LogEntry) []byte { return []byte(entry.Level.String() + " " + entry.Message + "\n") })
+
+	logger.Trace("hidden %d", 1)
+	logger.Tracew("hidden")
+	if buffer.Len() != 0 {
+		t.Fatal("Expected Trace to be excluded by default, got:", buffer.String())
+	}
+
+	logger.SetLevel(LevelTrace)
+	logger.Trace("frame %d", 1)
+	logger.Tracew("frame", "n", 2)
+	if buffer.String() != "TRACE frame 1\nTRACE frame\n" {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+}
@@ -0,0 +1,237 @@
+// Package loghttp provides an http.Handler to inspect and change the levels of
+// logx loggers on a running process
+//
+// GET returns the state of the global logger and every logger created with
+// logx.Named or registered with Register as JSON, where null marks a level or
+// vmodule rules a named logger inherits from its parent:
+//
+//	{"level":"INFO","vmodule":"db=debug","loggers":{"db":{"level":"DEBUG","vmodule":null}}}
+//
+// PUT accepts the same document, fields that are left out or unchanged are not
+// applied, a null vmodule keeps the rules and "level": null makes a named logger
+// inherit its parent's level again, so sending back a GET document pins nothing.
+// The whole document is validated before anything is applied, so a request
+// either changes everything it names or nothing.
+package loghttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/chihqiang/logx"
+)
+
+// maxBodySize limits PUT request bodies
+const maxBodySize = 1 << 20
+
+// LoggerState is the level state of one Logger
+// GET serves nil fields for the settings a named logger inherits, PUT leaves nil
+// fields unchanged, except a level given as null, see ResetLevel
+type LoggerState struct {
+	Level   *logx.Level `json:"level"`   // Minimum level, as a name such as "debug" or an integer
+	VModule *string     `json:"vmodule"` // Rules for Logger.SetVModule, "" removes them
+
+	// ResetLevel makes the Logger inherit its parent's level with Logger.ResetLevel,
+	// set by PUT for a level given as null
+	ResetLevel bool `json:"-"`
+}
+
+// State is the document served and accepted by the Handler
+type State struct {
+	LoggerState                        // State of the global logger
+	Loggers     map[string]LoggerState `json:"loggers,omitempty"` // State of registered loggers by name
+}
+
+// Handler serves the level state of the global logger, named loggers and registered loggers
+type Handler struct {
+	mu      sync.RWMutex
+	std     *logx.Logger            // Global logger, logx.Default() unless replaced in tests
+	loggers map[string]*logx.Logger // Registered loggers by name
+}
+
+// NewHandler returns a Handler for the global logger and the loggers created with logx.Named
+// Other loggers can be added with Register
+func NewHandler() *Handler {
+	return &Handler{std: logx.Default(), loggers: make(map[string]*logx.Logger)}
+}
+
+// Register makes a Logger available under name, replacing any Logger registered before
+// or created with logx.Named under the same name
+// Loggers derived with With or WithPrefix share their level with their parent, so
+// registering one of them is enough
+func (h *Handler) Register(name string, l *logx.Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.loggers[name] = l
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		state, err := decodeState(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		if status, err := h.apply(state); err != nil {
+			writeError(w, status, err)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeJSON(w, http.StatusOK, h.state())
+}
+
+// decodeState decodes a PUT body, marking the loggers whose level is null
+// The body is decoded twice, as a null level cannot be told apart from a missing one
+// once decoded into a LoggerState
+func decodeState(r io.Reader) (State, error) {
+	var state State
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return state, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&state); err != nil {
+		return state, err
+	}
+	type levelOnly struct {
+		Level json.RawMessage `json:"level"`
+	}
+	var levels struct {
+		levelOnly
+		Loggers map[string]levelOnly `json:"loggers"`
+	}
+	if err := json.Unmarshal(body, &levels); err != nil {
+		return state, err
+	}
+	state.ResetLevel = isNull(levels.Level)
+	for name, l := range levels.Loggers {
+		if ls := state.Loggers[name]; isNull(l.Level) {
+			ls.ResetLevel = true
+			state.Loggers[name] = ls
+		}
+	}
+	return state, nil
+}
+
+// isNull reports whether raw is the JSON null literal
+func isNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+// all returns the named loggers and the registered loggers by name
+// h.mu must be held
+func (h *Handler) all() map[string]*logx.Logger {
+	loggers := logx.NamedLoggers()
+	for name, l := range h.loggers {
+		loggers[name] = l
+	}
+	return loggers
+}
+
+// state returns the current state of all loggers
+func (h *Handler) state() State {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	state := State{LoggerState: stateOf(h.std)}
+	if loggers := h.all(); len(loggers) > 0 {
+		state.Loggers = make(map[string]LoggerState, len(loggers))
+		for name, l := range loggers {
+			state.Loggers[name] = stateOf(l)
+		}
+	}
+	return state
+}
+
+// apply validates the whole state first and then applies it
+// It returns the HTTP status to report along with any error
+func (h *Handler) apply(state State) (int, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	type change struct {
+		logger *logx.Logger
+		state  LoggerState
+	}
+	if state.ResetLevel {
+		return http.StatusBadRequest, fmt.Errorf("the global logger has no parent to inherit the level from")
+	}
+	changes := []change{{h.std, state.LoggerState}}
+	named, loggers := logx.NamedLoggers(), h.all()
+	names := make([]string, 0, len(state.Loggers))
+	for name := range state.Loggers {
+		names = append(names, name)
+	}
+	// Report errors deterministically
+	sort.Strings(names)
+	for _, name := range names {
+		l, ok := loggers[name]
+		if !ok {
+			return http.StatusNotFound, fmt.Errorf("unknown logger %q", name)
+		}
+		// Only loggers created with logx.Named have a parent
+		if state.Loggers[name].ResetLevel && named[name] != l {
+			return http.StatusBadRequest, fmt.Errorf("logger %q has no parent to inherit the level from", name)
+		}
+		changes = append(changes, change{l, state.Loggers[name]})
+	}
+	for _, c := range changes {
+		if c.state.VModule != nil {
+			// Validate against a throwaway Logger so nothing is applied yet
+			if err := logx.New(nil).SetVModule(*c.state.VModule); err != nil {
+				return http.StatusBadRequest, err
+			}
+		}
+	}
+
+	// Skip unchanged values, so that a document read with GET leaves inherited settings alone
+	for _, c := range changes {
+		if vmodule := c.state.VModule; vmodule != nil && (c.logger.VModuleInherited() || *vmodule != c.logger.VModule()) {
+			_ = c.logger.SetVModule(*vmodule)
+		}
+		if level := c.state.Level; level != nil && (c.logger.LevelInherited() || *level != c.logger.GetLevel()) {
+			c.logger.SetLevel(*level)
+		} else if c.state.ResetLevel {
+			c.logger.ResetLevel()
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// stateOf returns the state of l, without the settings it inherits
+func stateOf(l *logx.Logger) LoggerState {
+	var state LoggerState
+	if !l.LevelInherited() {
+		level := l.GetLevel()
+		state.Level = &level
+	}
+	if !l.VModuleInherited() {
+		vmodule := l.VModule()
+		state.VModule = &vmodule
+	}
+	return state
+}
+
+// writeJSON writes v as the response body with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as a JSON error document
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
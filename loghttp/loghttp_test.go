@@ -0,0 +1,214 @@
+package loghttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chihqiang/logx"
+)
+
+// newTestHandler returns a Handler for a fresh global logger and one registered "db" logger
+func newTestHandler() (*Handler, *logx.Logger, *logx.Logger) {
+	std := logx.New(&bytes.Buffer{})
+	db := logx.New(&bytes.Buffer{})
+	h := NewHandler()
+	h.std = std
+	h.Register("db", db)
+	return h, std, db
+}
+
+// do sends a request to h and decodes the JSON response
+func do(t *testing.T, h http.Handler, method, body string) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/", strings.NewReader(body)))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Expected JSON response, got Content-Type %q", ct)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a JSON body, got %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, doc
+}
+
+func TestGet(t *testing.T) {
+	// Test reading the state of the global and registered loggers
+	h, std, db := newTestHandler()
+	std.SetLevel(logx.LevelInfo)
+	db.SetLevel(logx.LevelWarn)
+	_ = db.SetVModule("conn.go=trace")
+
+	code, doc := do(t, h, http.MethodGet, "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if doc["level"] != "INFO" || doc["vmodule"] != "" {
+		t.Fatalf("Unexpected global state %v", doc)
+	}
+	loggers := doc["loggers"].(map[string]interface{})
+	if dbState := loggers["db"].(map[string]interface{}); dbState["level"] != "WARN" || dbState["vmodule"] != "conn.go=trace" {
+		t.Fatalf("Unexpected db state %v", dbState)
+	}
+}
+
+func TestPut(t *testing.T) {
+	// Test changing levels and vmodule rules, leaving out fields keeps them
+	h, std, db := newTestHandler()
+	std.SetLevel(logx.LevelInfo)
+	_ = std.SetVModule("http/*=warn")
+
+	code, doc := do(t, h, http.MethodPut, `{"level":"debug","loggers":{"db":{"level":"ERROR","vmodule":"db=trace"}}}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, doc)
+	}
+	if std.GetLevel() != logx.LevelDebug || std.VModule() != "http/*=warn" {
+		t.Fatalf("Unexpected global state %v %q", std.GetLevel(), std.VModule())
+	}
+	if db.GetLevel() != logx.LevelError || db.VModule() != "db=trace" {
+		t.Fatalf("Unexpected db state %v %q", db.GetLevel(), db.VModule())
+	}
+	// The response is the new state
+	if doc["level"] != "DEBUG" {
+		t.Fatalf("Expected the new state in the response, got %v", doc)
+	}
+
+	// Integer levels and clearing rules
+	if code, doc = do(t, h, http.MethodPut, `{"level":4,"vmodule":""}`); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, doc)
+	}
+	if std.GetLevel() != logx.LevelWarn || std.VModule() != "" {
+		t.Fatalf("Unexpected global state %v %q", std.GetLevel(), std.VModule())
+	}
+}
+
+func TestNamed(t *testing.T) {
+	// Test that loggers created with logx.Named are served, and that a null level inherits again
+	h, _, _ := newTestHandler()
+	parent, pool := logx.Named("loghttp_test"), logx.Named("loghttp_test.pool")
+	parent.SetLevel(logx.LevelWarn)
+	defer parent.ResetLevel()
+
+	code, doc := do(t, h, http.MethodPut, `{"loggers":{"loghttp_test.pool":{"level":"trace"}}}`)
+	if code != http.StatusOK || pool.GetLevel() != logx.LevelTrace {
+		t.Fatalf("Expected the named logger to be changed, got %d %v", code, doc)
+	}
+	loggers := doc["loggers"].(map[string]interface{})
+	if state := loggers["loghttp_test"].(map[string]interface{}); state["level"] != "WARN" {
+		t.Fatalf("Unexpected parent state %v", state)
+	}
+
+	code, doc = do(t, h, http.MethodPut, `{"loggers":{"loghttp_test.pool":{"level":null}}}`)
+	if code != http.StatusOK || pool.GetLevel() != logx.LevelWarn {
+		t.Fatalf("Expected the parent level to be inherited again, got %d %v", code, doc)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	// Test that sending back a GET document keeps named loggers inheriting from their parent
+	h, _, _ := newTestHandler()
+	parent, child := logx.Named("roundtrip_test"), logx.Named("roundtrip_test.child")
+	parent.SetLevel(logx.LevelWarn)
+	_ = parent.SetVModule("conn.go=trace")
+	defer func() {
+		parent.ResetLevel()
+		_ = parent.SetVModule("")
+	}()
+
+	code, doc := do(t, h, http.MethodGet, "")
+	state := doc["loggers"].(map[string]interface{})["roundtrip_test.child"].(map[string]interface{})
+	if code != http.StatusOK || state["level"] != nil || state["vmodule"] != nil {
+		t.Fatalf("Expected inherited settings to be served as null, got %d %v", code, state)
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, doc = do(t, h, http.MethodPut, string(body)); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, doc)
+	}
+	if !child.LevelInherited() || !child.VModuleInherited() {
+		t.Fatal("Expected the round trip to leave the child inheriting")
+	}
+
+	// Later parent changes still reach the child
+	parent.SetLevel(logx.LevelError)
+	if child.GetLevel() != logx.LevelError {
+		t.Fatal("Expected the child to follow the parent, got:", child.GetLevel())
+	}
+}
+
+func TestPutInvalid(t *testing.T) {
+	// Test that invalid requests are rejected without changing anything
+	h, std, db := newTestHandler()
+	std.SetLevel(logx.LevelInfo)
+	db.SetLevel(logx.LevelInfo)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"level":"verbose"}`, http.StatusBadRequest},
+		{`{"level":"debug","loggers":{"db":{"vmodule":"db"}}}`, http.StatusBadRequest},
+		{`{"level":"debug","loggers":{"cache":{"level":"debug"}}}`, http.StatusNotFound},
+		{`{"levels":"debug"}`, http.StatusBadRequest},
+		{`{"level":null}`, http.StatusBadRequest},
+		{`{"level":"debug","loggers":{"db":{"level":null}}}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		code, doc := do(t, h, http.MethodPut, tt.body)
+		if code != tt.code || doc["error"] == nil {
+			t.Errorf("PUT %s: expected %d with an error, got %d %v", tt.body, tt.code, code, doc)
+		}
+	}
+	if std.GetLevel() != logx.LevelInfo || db.GetLevel() != logx.LevelInfo {
+		t.Fatal("Expected rejected requests to change nothing")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") == "" {
+		t.Fatalf("Expected 405 with Allow, got %d", rec.Code)
+	}
+}
+
+func TestPutTakesEffect(t *testing.T) {
+	// Test that a served change applies to the next log call, with -race against concurrent logging
+	var out bytes.Buffer
+	std := logx.New(&out)
+	std.SetFormatter(func(entry logx.LogEntry) []byte { return []byte(entry.Message + "\n") })
+	std.SetLevel(logx.LevelInfo)
+	h := NewHandler()
+	h.std = std
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	done := make(chan struct{})
+	child := logx.New(&bytes.Buffer{})
+	h.Register("child", child)
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			child.Debug("concurrent")
+		}
+	}()
+
+	std.Debug("before")
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"level":"debug","loggers":{"child":{"level":"debug"}}}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	std.Debug("after")
+	<-done
+
+	if out.String() != "after\n" {
+		t.Fatal("Unexpected output:", out.String())
+	}
+}
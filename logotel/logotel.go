@@ -0,0 +1,96 @@
+// Package logotel correlates logx entries with OpenTelemetry traces
+//
+// Extract adds the IDs of the span active in a context to entries logged with the
+// logx ...Context functions, and EventHook records entries as events on that span:
+//
+//	logx.RegisterContextExtractor(logotel.Extract)
+//	logx.Default().AddHook(logotel.EventHook(logx.LevelError))
+//
+//	logx.ErrorContext(ctx, "payment failed")
+//	// ... payment failed trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01
+//
+// It is a separate module so that programs not using OpenTelemetry do not depend on it.
+package logotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chihqiang/logx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field keys added by Extract
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// Extract is a logx.ContextExtractor returning the trace ID, span ID and trace flags
+// of the span context in ctx, as hex strings, or nil if ctx has no valid span context
+func Extract(ctx context.Context) logx.Fields {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return logx.Fields{
+		{Key: TraceIDKey, Value: sc.TraceID().String()},
+		{Key: SpanIDKey, Value: sc.SpanID().String()},
+		{Key: TraceFlagsKey, Value: sc.TraceFlags().String()},
+	}
+}
+
+// EventHook returns a logx.Hook recording entries at or above level as events on the
+// recording span in their context, so they show up on the trace
+// The event is named after the message and carries the level, caller and fields of
+// the entry as attributes, entries logged without a context are left alone
+func EventHook(level logx.Level) logx.Hook {
+	return func(entry *logx.LogEntry) {
+		if entry.Level < level || entry.Context == nil {
+			return
+		}
+		span := trace.SpanFromContext(entry.Context)
+		if !span.IsRecording() {
+			return
+		}
+		attrs := make([]attribute.KeyValue, 0, len(entry.Fields)+3)
+		attrs = append(attrs,
+			attribute.String("log.severity", entry.Level.String()),
+			attribute.String("code.filepath", entry.File),
+			attribute.Int("code.lineno", entry.Line),
+		)
+		for _, f := range entry.Fields {
+			switch f.Key {
+			case TraceIDKey, SpanIDKey, TraceFlagsKey:
+				// Already known to the span
+				continue
+			}
+			attrs = append(attrs, toAttribute(f))
+		}
+		span.AddEvent(entry.Message, trace.WithTimestamp(entry.Time), trace.WithAttributes(attrs...))
+	}
+}
+
+// toAttribute converts a field to an attribute, keeping basic types and formatting others as text
+func toAttribute(f logx.Field) attribute.KeyValue {
+	switch v := f.Value.(type) {
+	case string:
+		return attribute.String(f.Key, v)
+	case bool:
+		return attribute.Bool(f.Key, v)
+	case int:
+		return attribute.Int(f.Key, v)
+	case int64:
+		return attribute.Int64(f.Key, v)
+	case float64:
+		return attribute.Float64(f.Key, v)
+	case error:
+		return attribute.String(f.Key, v.Error())
+	case fmt.Stringer:
+		return attribute.String(f.Key, v.String())
+	default:
+		return attribute.String(f.Key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,83 @@
+package logotel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chihqiang/logx"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTracer returns a tracer whose finished spans are recorded by the returned exporter
+func newTracer(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return provider, exporter
+}
+
+func TestExtract(t *testing.T) {
+	// Test that the span IDs are added to entries logged with a span context
+	provider, _ := newTracer(t)
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+	sc := span.SpanContext()
+
+	if fields := Extract(context.Background()); fields != nil {
+		t.Fatal("Expected no fields without a span, got:", fields)
+	}
+	fields := Extract(ctx)
+	if len(fields) != 3 || fields[0].Value != sc.TraceID().String() ||
+		fields[1].Value != sc.SpanID().String() || fields[2].Value != "01" {
+		t.Fatal("Unexpected fields:", fields)
+	}
+
+	var buffer bytes.Buffer
+	logger := logx.New(&buffer)
+	logger.SetFormatter(logx.LogfmtFormatter)
+	logx.RegisterContextExtractor(Extract)
+	logger.InfoContext(ctx, "handled")
+	want := "msg=handled trace_id=" + sc.TraceID().String() + " span_id=" + sc.SpanID().String() + " trace_flags=01"
+	if !strings.Contains(buffer.String(), want) {
+		t.Fatalf("Expected %q, got %q", want, buffer.String())
+	}
+}
+
+func TestEventHook(t *testing.T) {
+	// Test that entries at or above the level are recorded as span events
+	provider, exporter := newTracer(t)
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	logger, err := logx.NewWithOptions(
+		logx.WithOutput(&bytes.Buffer{}),
+		logx.WithHooks(EventHook(logx.LevelError)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.With("order", 7).ErrorContext(ctx, "payment failed: %v", errors.New("declined"))
+	logger.WarnContext(ctx, "retrying")
+	logger.Error("no context")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || len(spans[0].Events) != 1 {
+		t.Fatal("Expected one span with one event, got:", spans)
+	}
+	event := spans[0].Events[0]
+	if event.Name != "payment failed: declined" {
+		t.Fatal("Unexpected event name:", event.Name)
+	}
+	attrs := make(map[string]string)
+	for _, kv := range event.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["log.severity"] != "ERROR" || attrs["order"] != "7" || !strings.HasSuffix(attrs["code.filepath"], "logotel_test.go") {
+		t.Fatal("Unexpected event attributes:", attrs)
+	}
+}
@@ -0,0 +1,248 @@
+package logx
+
+import (
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// levelInherit is the level of a named Logger that has not set its own
+const levelInherit = math.MinInt32
+
+var (
+	namedMu sync.RWMutex       // Guards named
+	named   map[string]*Logger // Named loggers by name, created lazily by Named
+)
+
+// Named returns the shared Logger with the given dot-separated name, creating it on first use
+// A named Logger inherits the level of its parent, e.g. "db" for "db.pool" and the global
+// Logger for "db", until its own level is set, so a parent's level change reaches all
+// descendants that have not overridden it
+// Likewise each of its output, color mode, formatter, exit and panic functions is that of
+// its parent until it is set on the named Logger, so changing one of them, e.g. the
+// formatter, keeps following the parent for the others
+// Sinks and hooks added to a named Logger are used in addition to those of its ancestors,
+// until SetSinks replaces the sinks it inherits
+// Vmodule rules are inherited as long as none are set on the named Logger
+// The name is used as the prefix, an empty name returns the global Logger
+func Named(name string) *Logger {
+	if name == "" {
+		return _std()
+	}
+	namedMu.RLock()
+	l, ok := named[name]
+	namedMu.RUnlock()
+	if ok {
+		return l
+	}
+
+	// Create the parent first, without holding the lock
+	parent := _std()
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		parent = Named(name[:i])
+	}
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	if l, ok := named[name]; ok {
+		return l
+	}
+	l = &Logger{
+		core:   &core{parent: parent.core, level: levelInherit, inflight: new(sync.WaitGroup)},
+		prefix: name,
+	}
+	if named == nil {
+		named = make(map[string]*Logger)
+	}
+	named[name] = l
+	return l
+}
+
+// NamedLoggers returns the loggers created with Named so far, by name
+func NamedLoggers() map[string]*Logger {
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+	loggers := make(map[string]*Logger, len(named))
+	for name, l := range named {
+		loggers[name] = l
+	}
+	return loggers
+}
+
+// ResetLevel makes a named Logger inherit its parent's level again (thread-safe)
+// It has no effect on other loggers
+func (l *Logger) ResetLevel() {
+	if l.core.parent != nil {
+		atomic.StoreInt32(&l.core.level, levelInherit)
+	}
+}
+
+// LevelInherited reports whether l uses its parent's level, for a named Logger whose
+// level was never set or was reset with ResetLevel
+func (l *Logger) LevelInherited() bool {
+	return l.core.parent != nil && atomic.LoadInt32(&l.core.level) == levelInherit
+}
+
+// VModuleInherited reports whether l uses the vmodule rules of its ancestors, for a
+// named Logger without rules of its own
+func (l *Logger) VModuleInherited() bool {
+	if l.core.parent == nil {
+		return false
+	}
+	vm, _ := l.core.vmodule.Load().(*vmodule)
+	return vm == nil
+}
+
+// getLevel returns the level of c, or of its nearest ancestor with a level set
+func (c *core) getLevel() Level {
+	for {
+		level := atomic.LoadInt32(&c.level)
+		if level != levelInherit || c.parent == nil {
+			return Level(level)
+		}
+		c = c.parent
+	}
+}
+
+// Output settings a named Logger inherits from its parent until they are set on it
+const (
+	ownWriter = 1 << iota
+	ownColor
+	ownFormatter
+	ownSinks
+	ownExit
+	ownPanic
+)
+
+// outputGen counts changes of the writer or color mode of any core, starting at 1, so
+// named loggers know when the color decision they cached for inherited settings is stale
+var outputGen uint64 = 1
+
+// outputChanged invalidates the cached color decisions after a writer or color mode change
+// It must be called with the lock of the changed core held, after the change
+func outputChanged() {
+	atomic.AddUint64(&outputGen, 1)
+}
+
+// outputState is the output state used for an entry, resolved through the ancestors of
+// named loggers so changes on an ancestor apply to the settings a Logger inherits
+type outputState struct {
+	writer    io.Writer
+	formatter Formatter
+	color     bool
+	sinks     []*Sink
+	now       func() time.Time
+	onError   func(error)
+	hooks     []Hook
+	inflight  *sync.WaitGroup   // Write in progress registered on the core of the Logger
+	ancestors []*sync.WaitGroup // Writes in progress registered on the ancestors of a named Logger
+}
+
+// resolve returns the output state of c and registers a write in progress on c and
+// each of its ancestors, to be finished with done
+// Sinks and hooks added to a named Logger come after those of its ancestors, unless
+// its sinks were replaced with SetSinks
+func (c *core) resolve() outputState {
+	var out outputState
+	leaf, gen := c, atomic.LoadUint64(&outputGen)
+	pending := uint8(ownWriter | ownColor | ownFormatter | ownSinks)
+	var mode ColorMode
+	var colorCached bool
+	for ; c != nil; c = c.parent {
+		c.mu.RLock()
+		own := c.own
+		if c.parent == nil {
+			own = pending
+		}
+		if pending&own&ownWriter != 0 && pending&own&ownColor != 0 {
+			// The color decision of a core is only kept for its own writer and mode
+			out.color, colorCached = c.color, true
+		}
+		if pending&own&ownWriter != 0 {
+			out.writer = c.writer
+		}
+		if pending&own&ownColor != 0 {
+			mode = c.colorMode
+		}
+		if pending&own&ownFormatter != 0 {
+			out.formatter = c.formatter
+		}
+		if pending&ownSinks != 0 {
+			out.sinks = joinSinks(c.sinks, out.sinks)
+		}
+		out.hooks = joinHooks(c.hooks, out.hooks)
+		if out.now == nil {
+			out.now = c.now
+		}
+		if out.onError == nil {
+			out.onError = c.onError
+		}
+		c.inflight.Add(1)
+		if out.inflight == nil {
+			out.inflight = c.inflight
+		} else {
+			out.ancestors = append(out.ancestors, c.inflight)
+		}
+		c.mu.RUnlock()
+		pending &^= own
+	}
+	if !colorCached {
+		// Writer and mode come from different cores, decide once per change rather than per entry
+		if cached := atomic.LoadUint64(&leaf.colorCache); cached>>1 == gen {
+			out.color = cached&1 == 1
+		} else {
+			out.color = useColor(mode, out.writer)
+			cached = gen << 1
+			if out.color {
+				cached |= 1
+			}
+			atomic.StoreUint64(&leaf.colorCache, cached)
+		}
+	}
+	return out
+}
+
+// done finishes the writes in progress registered by resolve
+func (o *outputState) done() {
+	o.inflight.Done()
+	for _, wg := range o.ancestors {
+		wg.Done()
+	}
+}
+
+// joinSinks returns the sinks of an ancestor followed by those of its descendants
+func joinSinks(ancestor, descendants []*Sink) []*Sink {
+	if len(descendants) == 0 {
+		return ancestor
+	}
+	if len(ancestor) == 0 {
+		return descendants
+	}
+	return append(append(make([]*Sink, 0, len(ancestor)+len(descendants)), ancestor...), descendants...)
+}
+
+// joinHooks returns the hooks of an ancestor followed by those of its descendants
+func joinHooks(ancestor, descendants []Hook) []Hook {
+	if len(descendants) == 0 {
+		return ancestor
+	}
+	if len(ancestor) == 0 {
+		return descendants
+	}
+	return append(append(make([]Hook, 0, len(ancestor)+len(descendants)), ancestor...), descendants...)
+}
+
+// lookup returns the nearest core from c up that has the setting set, or the root core
+func (c *core) lookup(setting uint8) *core {
+	for ; c.parent != nil; c = c.parent {
+		c.mu.RLock()
+		own := c.own&setting != 0
+		c.mu.RUnlock()
+		if own {
+			return c
+		}
+	}
+	return c
+}
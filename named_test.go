@@ -0,0 +1,252 @@
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// resetNamed replaces the global Logger with one writing to w and forgets all named loggers
+// With a nil w the global Logger is created again on next use
+func resetNamed(t *testing.T, w *bytes.Buffer) {
+	t.Helper()
+	std = nil
+	stdOnce = sync.Once{}
+	if w != nil {
+		stdOnce.Do(func() { std = New(w) })
+	}
+	namedMu.Lock()
+	named = nil
+	namedMu.Unlock()
+}
+
+func TestNamed(t *testing.T) {
+	// Test that named loggers are shared and inherit the level of their ancestors
+	var buffer bytes.Buffer
+	resetNamed(t, &buffer)
+	defer resetNamed(t, nil)
+
+	pool := Named("db.pool")
+	if Named("db.pool") != pool || Named("") != Default() {
+		t.Fatal("Expected Named to return shared loggers")
+	}
+	if pool.GetLevel() != LevelDebug {
+		t.Fatal("Expected the global level to be inherited, got:", pool.GetLevel())
+	}
+
+	// A change on the root reaches all descendants
+	SetLevel(LevelWarn)
+	if pool.GetLevel() != LevelWarn || Named("db").GetLevel() != LevelWarn {
+		t.Fatal("Expected the root level to propagate, got:", pool.GetLevel())
+	}
+
+	// An overridden level applies to descendants but not to ancestors
+	Named("db").SetLevel(LevelTrace)
+	if pool.GetLevel() != LevelTrace || GetLevel() != LevelWarn || Named("http").GetLevel() != LevelWarn {
+		t.Fatal("Expected the override to apply to db and below only")
+	}
+	pool.SetLevel(LevelError)
+	SetLevel(LevelInfo)
+	if pool.GetLevel() != LevelError || Named("db").GetLevel() != LevelTrace {
+		t.Fatal("Expected overridden levels to be kept")
+	}
+	pool.ResetLevel()
+	if pool.GetLevel() != LevelTrace {
+		t.Fatal("Expected ResetLevel to inherit again, got:", pool.GetLevel())
+	}
+	Default().ResetLevel()
+	if GetLevel() != LevelInfo {
+		t.Fatal("Expected ResetLevel to leave the root alone, got:", GetLevel())
+	}
+
+	// Named loggers write to the root output with their name as the prefix
+	pool.Tracew("acquired", "conn", 3)
+	if !strings.Contains(buffer.String(), "db.pool: acquired") || !strings.Contains(buffer.String(), "conn=3") {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+}
+
+func TestNamedOutput(t *testing.T) {
+	// Test that each output setting is inherited until it is set on a named Logger
+	var root, other, audit bytes.Buffer
+	resetNamed(t, &root)
+	defer resetNamed(t, nil)
+
+	pool := Named("db.pool")
+	SetFormatter(LogfmtFormatter)
+	SetOutput(&other)
+	pool.Info("one")
+	if root.Len() != 0 || !strings.Contains(other.String(), "msg=one") {
+		t.Fatalf("Expected the root output state to be followed, got %q and %q", root.String(), other.String())
+	}
+
+	// A sink added to db is used in addition to the inherited output, by db and below only
+	Named("db").AddSink(NewSink(&audit, LogfmtFormatter, LevelWarn))
+	pool.Warn("two")
+	Warn("three")
+	if !strings.Contains(audit.String(), "msg=two") || strings.Contains(audit.String(), "three") {
+		t.Fatal("Unexpected sink output:", audit.String())
+	}
+	if !strings.Contains(other.String(), "msg=two") || !strings.Contains(other.String(), "msg=three") {
+		t.Fatal("Unexpected root output:", other.String())
+	}
+
+	// Root changes still reach the settings db has not set
+	SetOutput(&root)
+	SetFormatter(JSONFormatter(JSONOptions{}))
+	pool.Warn("four")
+	if !strings.Contains(root.String(), `"message":"four"`) || !strings.Contains(audit.String(), "msg=four") {
+		t.Fatalf("Expected db to follow the root output, got %q and %q", root.String(), audit.String())
+	}
+
+	// Overriding the formatter on db keeps following the root output
+	root.Reset()
+	Named("db").SetFormatter(LogfmtFormatter)
+	SetOutput(&other)
+	pool.Info("five")
+	Info("six")
+	if root.Len() != 0 || !strings.Contains(other.String(), "msg=five") || !strings.Contains(other.String(), `"message":"six"`) {
+		t.Fatalf("Expected only the formatter to be overridden, got %q and %q", root.String(), other.String())
+	}
+
+	// SetSinks replaces the inherited sinks
+	Default().AddSink(NewSink(&root, LogfmtFormatter, LevelInfo))
+	pool.SetSinks()
+	pool.Info("seven")
+	Named("db").Info("eight")
+	if strings.Contains(root.String(), "seven") || !strings.Contains(root.String(), "msg=eight") {
+		t.Fatal("Unexpected sink output:", root.String())
+	}
+}
+
+func TestNamedApplyConfig(t *testing.T) {
+	// Test that reloading the root config reaches a named Logger that only overrides its formatter
+	var buffer bytes.Buffer
+	resetNamed(t, &buffer)
+	defer resetNamed(t, nil)
+
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")}
+	db := Named("db")
+	db.SetFormatter(LogfmtFormatter)
+	for i, path := range paths {
+		if err := Default().ApplyConfig(Config{OutputConfig: OutputConfig{Output: path, Format: "json"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Log(LevelInfo, "query %d", i); err != nil {
+			t.Fatal("Expected the reloaded output to be used, got:", err)
+		}
+	}
+	if err := Default().Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), fmt.Sprintf(`msg="query %d"`, i)) {
+			t.Fatalf("Unexpected output in %s: %s", path, data)
+		}
+	}
+	if err := db.Log(LevelInfo, "closed"); err == nil {
+		t.Fatal("Expected Close on the root to close the output of db")
+	}
+}
+
+func TestNamedClose(t *testing.T) {
+	// Test that Sync and Close on a named Logger leave the inherited writers alone, unlike Fatal
+	root, rootSink, own := &lifecycleWriter{}, &lifecycleWriter{}, &lifecycleWriter{}
+	std = nil
+	stdOnce = sync.Once{}
+	stdOnce.Do(func() { std = New(root) })
+	defer resetNamed(t, nil)
+	Default().AddSink(NewSink(rootSink, nil, LevelDebug))
+
+	db := Named("db")
+	if err := db.Close(); err != nil || len(root.snapshot())+len(rootSink.snapshot()) != 0 {
+		t.Fatalf("Expected the inherited writers to stay open, got %v and %v", root.snapshot(), rootSink.snapshot())
+	}
+	db.AddSink(NewSink(own, nil, LevelDebug))
+	if err := db.Close(); err != nil || own.count("close") != 1 || root.count("close") != 0 {
+		t.Fatalf("Expected only the own sink to be closed, got %v and %v", own.snapshot(), root.snapshot())
+	}
+
+	db.SetExitFunc(func(int) {})
+	db.Fatal("bye")
+	if root.count("flush") != 1 || rootSink.count("flush") != 1 || root.count("close") != 0 {
+		t.Fatalf("Expected Fatal to sync the inherited writers, got %v and %v", root.snapshot(), rootSink.snapshot())
+	}
+}
+
+func TestNamedColor(t *testing.T) {
+	// Test that the color decision follows the root when a named Logger only sets its writer
+	var root, own bytes.Buffer
+	resetNamed(t, &root)
+	defer resetNamed(t, nil)
+
+	db := Named("db")
+	db.SetOutput(&own)
+	var colored bool
+	db.AddHook(func(entry *LogEntry) { colored = entry.Color })
+	for _, mode := range []ColorMode{ColorAlways, ColorNever, ColorAlways} {
+		SetColor(mode)
+		db.Info("query")
+		if colored != (mode == ColorAlways) {
+			t.Fatalf("Expected color %v with mode %v", !colored, mode)
+		}
+	}
+}
+
+func TestNamedVModule(t *testing.T) {
+	// Test that vmodule rules are inherited and match the name used as prefix
+	var buffer bytes.Buffer
+	resetNamed(t, &buffer)
+	defer resetNamed(t, nil)
+
+	SetLevel(LevelWarn)
+	if err := SetVModule("db.*=debug"); err != nil {
+		t.Fatal(err)
+	}
+	Named("db.pool").Debug("pool")
+	Named("db").Debug("db")
+	if !strings.Contains(buffer.String(), "db.pool: pool") || strings.Contains(buffer.String(), "db: db") {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+	if Named("db").VModule() != "db.*=debug" {
+		t.Fatal("Expected inherited rules, got:", Named("db").VModule())
+	}
+}
+
+func TestNamedConcurrent(t *testing.T) {
+	// Test concurrent creation, configuration and logging (run with -race)
+	var buffer safeWriter
+	resetNamed(t, &bytes.Buffer{})
+	defer resetNamed(t, nil)
+	SetOutput(&buffer)
+
+	var wg sync.WaitGroup
+	loggers := make([]*Logger, 8)
+	for i := range loggers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			loggers[i] = Named(fmt.Sprintf("svc.part%d.sub", i%2))
+			loggers[i].Info("created")
+			if i%3 == 0 {
+				Named("svc").SetLevel(LevelInfo)
+				Named("svc").SetFormatter(LogfmtFormatter)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, l := range loggers {
+		if l != loggers[i%2] {
+			t.Fatal("Expected one Logger per name")
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package logx
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Hook is called with every entry that passes the level check, before it is formatted
+// Hooks may modify the entry, e.g. to add fields, and run in the order they were added
+type Hook func(entry *LogEntry)
+
+// Option configures a Logger created by NewWithOptions
+type Option func(o *options) error
+
+// options collects the settings of NewWithOptions before the Logger is built
+type options struct {
+	writer     io.Writer
+	sinks      []*Sink
+	formatter  Formatter
+	prefix     string
+	level      Level
+	callerSkip int
+	now        func() time.Time
+	onError    func(error)
+	hooks      []Hook
+}
+
+// NewWithOptions creates a Logger fully configured by opts, so it is never live with
+// partial settings
+// Without options it writes text to os.Stderr at LevelDebug, like the global Logger
+// It returns an error if an option is invalid
+func NewWithOptions(opts ...Option) (*Logger, error) {
+	o := options{writer: os.Stderr, formatter: DefaultFormatter, level: LevelDebug, now: time.Now}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	l := New(o.writer)
+	l.prefix = o.prefix
+	l.callerSkip = 2 + o.callerSkip
+	l.core.formatter = o.formatter
+	l.core.sinks = o.sinks
+	l.core.level = int32(o.level)
+	l.core.now = o.now
+	l.core.onError = o.onError
+	l.core.hooks = o.hooks
+	return l, nil
+}
+
+// WithOutput sets the output destination, os.Stderr by default
+func WithOutput(w io.Writer) Option {
+	return func(o *options) error {
+		if w == nil {
+			return errors.New("logx: nil writer")
+		}
+		o.writer = w
+		return nil
+	}
+}
+
+// WithSinks adds outputs with their own writer, formatter and level, see AddSink
+func WithSinks(sinks ...*Sink) Option {
+	return func(o *options) error {
+		for _, s := range sinks {
+			if s == nil || s.writer == nil {
+				return errors.New("logx: nil sink or sink writer")
+			}
+		}
+		o.sinks = append(o.sinks, sinks...)
+		return nil
+	}
+}
+
+// WithFormatter sets the formatting function of the output, DefaultFormatter by default
+func WithFormatter(fn Formatter) Option {
+	return func(o *options) error {
+		if fn == nil {
+			return errors.New("logx: nil formatter")
+		}
+		o.formatter = fn
+		return nil
+	}
+}
+
+// WithLogPrefix sets the log prefix
+// It is not called WithPrefix to keep it apart from Logger.WithPrefix, which derives a Logger
+func WithLogPrefix(prefix string) Option {
+	return func(o *options) error {
+		o.prefix = prefix
+		return nil
+	}
+}
+
+// WithLevel sets the minimum level to output, LevelDebug by default
+func WithLevel(level Level) Option {
+	return func(o *options) error {
+		o.level = level
+		return nil
+	}
+}
+
+// WithCallerSkip skips n more stack frames when resolving the caller's file and line
+// Libraries wrapping the Logger in their own helpers pass the number of helper
+// frames between their callers and the Logger, so entries point at their callers
+func WithCallerSkip(n int) Option {
+	return func(o *options) error {
+		if n < 0 {
+			return errors.New("logx: negative caller skip")
+		}
+		o.callerSkip = n
+		return nil
+	}
+}
+
+// WithClock sets the function returning the time of each entry, time.Now by default
+func WithClock(now func() time.Time) Option {
+	return func(o *options) error {
+		if now == nil {
+			return errors.New("logx: nil clock")
+		}
+		o.now = now
+		return nil
+	}
+}
+
+// WithErrorHandler sets a function called with errors writing entries
+// Debug, Info and the other level methods have no error result, so without a
+// handler their write errors are lost
+func WithErrorHandler(fn func(err error)) Option {
+	return func(o *options) error {
+		o.onError = fn
+		return nil
+	}
+}
+
+// WithHooks adds hooks run on every entry before it is formatted
+func WithHooks(hooks ...Hook) Option {
+	return func(o *options) error {
+		for _, h := range hooks {
+			if h == nil {
+				return errors.New("logx: nil hook")
+			}
+		}
+		o.hooks = append(o.hooks, hooks...)
+		return nil
+	}
+}
+
+// AddHook adds a hook run on every entry before it is formatted (thread-safe)
+// The change is visible to all loggers sharing the same output state
+func (l *Logger) AddHook(hook Hook) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	// Copy on write so log calls can use the slice without holding the lock
+	hooks := make([]Hook, 0, len(l.core.hooks)+1)
+	l.core.hooks = append(append(hooks, l.core.hooks...), hook)
+}
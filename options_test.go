@@ -0,0 +1,128 @@
+package logx
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	// Test that all options are applied at construction
+	var primary, alerts bytes.Buffer
+	clock := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	logger, err := NewWithOptions(
+		WithOutput(&primary),
+		WithSinks(NewSink(&alerts, LogfmtFormatter, LevelError)),
+		WithFormatter(JSONFormatter(JSONOptions{})),
+		WithLogPrefix("db"),
+		WithLevel(LevelInfo),
+		WithClock(func() time.Time { return clock }),
+	)
+	if err != nil {
+		t.Fatal("Expected no error from NewWithOptions, got:", err)
+	}
+
+	logger.Debug("hidden")
+	logger.Error("failed")
+	if strings.Contains(primary.String(), "hidden") {
+		t.Fatal("Expected the level to be applied, got:", primary.String())
+	}
+	if !strings.HasPrefix(primary.String(), `{"time":"2023-01-01T12:00:00Z","level":"ERROR","prefix":"db"`) {
+		t.Fatal("Expected the formatter, prefix and clock to be applied, got:", primary.String())
+	}
+	if !strings.Contains(alerts.String(), "msg=failed") {
+		t.Fatal("Expected the sink to be added, got:", alerts.String())
+	}
+}
+
+func TestNewWithOptionsInvalid(t *testing.T) {
+	// Test that invalid options are rejected at construction
+	for _, opt := range []Option{
+		WithOutput(nil),
+		WithSinks(nil),
+		WithFormatter(nil),
+		WithCallerSkip(-1),
+		WithClock(nil),
+		WithHooks(nil),
+	} {
+		if logger, err := NewWithOptions(opt); err == nil || logger != nil {
+			t.Errorf("Expected an error and no Logger, got %v", err)
+		}
+	}
+}
+
+// logHelper is a library helper wrapping the Logger, one frame between its callers and the Logger
+func logHelper(logger *Logger, msg string) {
+	logger.Infow(msg, "helper", true)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	// Test that helpers wrapping the Logger report their callers' file and line
+	var captured LogEntry
+	logger, err := NewWithOptions(
+		WithFormatter(func(entry LogEntry) []byte { captured = entry; return nil }),
+		WithCallerSkip(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, line, _ := runtime.Caller(0)
+	logHelper(logger, "from helper")
+	if filepath.Base(captured.File) != "options_test.go" || captured.Line != line+1 {
+		t.Fatalf("Expected the helper's caller at line %d, got %s:%d", line+1, captured.File, captured.Line)
+	}
+
+	// Derived loggers keep the caller skip
+	logHelper(logger.With("k", "v"), "from child")
+	if captured.Line != line+7 {
+		t.Fatalf("Expected line %d, got %d", line+7, captured.Line)
+	}
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	// Test that write errors reach the handler even from methods without an error result
+	var handled []error
+	logger, err := NewWithOptions(WithOutput(errorWriter{}), WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("lost")
+	if len(handled) != 1 || handled[0].Error() != "write failed" {
+		t.Fatal("Expected the write error to be handled, got:", handled)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	// Test that hooks can modify entries before formatting, in order
+	var buffer bytes.Buffer
+	logger, err := NewWithOptions(
+		WithOutput(&buffer),
+		WithFormatter(LogfmtFormatter),
+		WithHooks(func(entry *LogEntry) {
+			entry.Fields = append(entry.Fields, Field{Key: "host", Value: "web-1"})
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.AddHook(func(entry *LogEntry) {
+		entry.Message = strings.ToUpper(entry.Message)
+	})
+
+	logger.With("k", 1).Infow("ready", "port", 80)
+	if !strings.Contains(buffer.String(), "msg=READY k=1 port=80 host=web-1") {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+
+	// Hooks do not run for disabled levels
+	buffer.Reset()
+	logger.SetLevel(LevelWarn)
+	logger.Info("hidden")
+	if buffer.Len() != 0 {
+		t.Fatal("Expected no output, got:", buffer.String())
+	}
+}
@@ -74,6 +74,123 @@ func BenchmarkGlobalLoggerSingle(b *testing.B) {
 	}
 }
 
+// BenchmarkLoggerDisabled benchmarks the cost of a log call below the minimum level
+func BenchmarkLoggerDisabled(b *testing.B) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetLevel(LevelInfo)
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logger.Debug("benchmark message: %d", i)
+	}
+}
+
+// BenchmarkLoggerDisabledParallel benchmarks disabled log calls under high concurrency
+func BenchmarkLoggerDisabledParallel(b *testing.B) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetLevel(LevelInfo)
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	// Parallel test
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Debug("benchmark message")
+		}
+	})
+}
+
+// BenchmarkGlobalLoggerDisabled benchmarks disabled log calls through the global logger
+func BenchmarkGlobalLoggerDisabled(b *testing.B) {
+	buffer := &bytes.Buffer{}
+
+	// Reset global logger
+	std = nil
+	stdOnce = sync.Once{}
+	SetOutput(buffer)
+	SetLevel(LevelInfo)
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Debug("benchmark message: %d", i)
+	}
+}
+
+// BenchmarkLoggerWith benchmarks creating a child logger per request and logging through it once
+// The child's fields are encoded on its first entry, so nothing is saved by the field cache here
+func BenchmarkLoggerWith(b *testing.B) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logger.With("request", i, "user", "bench").Info("benchmark message")
+	}
+}
+
+// BenchmarkLoggerWithReused benchmarks logging through a long-lived child logger
+// Its fields are encoded once per formatter and reused by every call
+func BenchmarkLoggerWithReused(b *testing.B) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetFormatter(JSONFormatter(JSONOptions{}))
+	child := logger.With("request", "r1", "user", "bench", "tenant", "acme")
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		child.Info("benchmark message")
+	}
+}
+
+// BenchmarkJSONFormatter benchmarks Logger performance with the built-in JSON formatter
+func BenchmarkJSONFormatter(b *testing.B) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetFormatter(JSONFormatter(JSONOptions{}))
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logger.Infow("benchmark message", "iteration", i, "user", "bench")
+	}
+}
+
+// BenchmarkLoggerAsyncParallel benchmarks Logger performance with an AsyncWriter under high concurrency
+func BenchmarkLoggerAsyncParallel(b *testing.B) {
+	writer := NewAsyncWriter(&safeWriter{}, AsyncOptions{QueueSize: 4096})
+	logger := New(writer)
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	// Parallel test
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info("benchmark message: %d", time.Now().UnixNano())
+		}
+	})
+	_ = writer.Close()
+}
+
 // safeWriter is a thread-safe writer
 // It uses mutex to protect internal bytes.Buffer
 // Used for high concurrency testing
@@ -183,3 +300,19 @@ func TestGlobalLoggerThroughput(t *testing.T) {
 		t.Errorf("Expected %d log lines, got %d", totalLogs, lines)
 	}
 }
+
+// BenchmarkVModule benchmarks a disabled log call decided by a cached vmodule rule
+func BenchmarkVModule(b *testing.B) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetLevel(LevelInfo)
+	_ = logger.SetVModule("other.go=trace")
+
+	b.ReportAllocs()
+	// Reset timer
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logger.Debug("benchmark message")
+	}
+}
@@ -0,0 +1,216 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects how rotated files are compressed
+type Compression int
+
+const (
+	NoCompression Compression = iota // Keep rotated files as they are
+	Gzip                             // Compress rotated files with gzip, adding ".gz"
+	Zstd                             // Compress rotated files with zstd, adding ".zst"
+)
+
+// String returns the string representation of the compression
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+// ext returns the file name suffix added by the compression
+func (c Compression) ext() string {
+	switch c {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// newWriter wraps w with a compressing writer
+func (c Compression) newWriter(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("rotate: unknown compression %d", c)
+	}
+}
+
+// needsMaintenance reports whether rotated files need any background processing
+func (f *RotatingFile) needsMaintenance() bool {
+	o := f.opts
+	return o.Compress != NoCompression || o.MaxBackups > 0 || o.MaxAge > 0 || o.MaxTotalSize > 0
+}
+
+// notify wakes the maintenance goroutine without blocking
+func (f *RotatingFile) notify() {
+	if f.wake == nil {
+		return
+	}
+	select {
+	case f.wake <- struct{}{}:
+	default:
+		// A pass is already pending and will see the new file
+	}
+}
+
+// report passes err to OnError if set
+func (f *RotatingFile) report(err error) {
+	if err != nil && f.opts.OnError != nil {
+		f.opts.OnError(err)
+	}
+}
+
+// maintain runs in the background, compressing and removing rotated files
+// when woken, and does a last pass when Close is called
+func (f *RotatingFile) maintain() {
+	defer close(f.done)
+	for {
+		select {
+		case <-f.wake:
+			f.process()
+		case <-f.quit:
+			f.process()
+			return
+		}
+	}
+}
+
+// process compresses rotated files, then applies MaxBackups, MaxAge and MaxTotalSize
+func (f *RotatingFile) process() {
+	files, err := f.list()
+	if err != nil {
+		f.report(fmt.Errorf("rotate: list files: %w", err))
+		return
+	}
+	// Read the current path after listing, so a file created by a concurrent
+	// rotation is either missing from the list or is the current file
+	f.mu.Lock()
+	current := f.path
+	f.mu.Unlock()
+	// Never consider the current file a backup
+	backups := make([]logFile, 0, len(files))
+	for _, b := range files {
+		if b.path != current {
+			backups = append(backups, b)
+		}
+	}
+
+	remove := f.expired(backups)
+	removed := make(map[string]bool, len(remove))
+	for _, b := range remove {
+		removed[b.path] = true
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			f.report(fmt.Errorf("rotate: remove %s: %w", b.path, err))
+		}
+	}
+
+	if f.opts.Compress == NoCompression {
+		return
+	}
+	for _, b := range backups {
+		if removed[b.path] || b.compression != NoCompression {
+			continue
+		}
+		if err := compressFile(b.path, f.opts.Compress); err != nil {
+			f.report(fmt.Errorf("rotate: compress %s: %w", b.path, err))
+		}
+	}
+}
+
+// expired returns the backups, oldest first, that exceed MaxBackups, MaxAge or MaxTotalSize
+func (f *RotatingFile) expired(backups []logFile) []logFile {
+	var remove []logFile
+	if n := f.opts.MaxBackups; n > 0 && len(backups) > n {
+		remove = append(remove, backups[:len(backups)-n]...)
+		backups = backups[len(backups)-n:]
+	}
+	if f.opts.MaxAge > 0 {
+		cutoff := f.opts.Now().Add(-f.opts.MaxAge)
+		kept := backups[:0:0]
+		for _, b := range backups {
+			if b.time.Before(cutoff) {
+				remove = append(remove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+	if f.opts.MaxTotalSize > 0 {
+		// Keep the newest files that fit within the budget
+		var total int64
+		for i := len(backups) - 1; i >= 0; i-- {
+			info, err := os.Stat(backups[i].path)
+			if err != nil {
+				continue
+			}
+			total += info.Size()
+			if total > f.opts.MaxTotalSize {
+				remove = append(remove, backups[:i+1]...)
+				break
+			}
+		}
+	}
+	return remove
+}
+
+// compressFile compresses path into path plus the compression suffix and removes the original
+// Data is written to a temporary file first so a partially compressed file is never visible
+func compressFile(path string, c Compression) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst := path + c.ext()
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	w, err := c.newWriter(out)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
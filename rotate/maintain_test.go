@@ -0,0 +1,197 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompression(t *testing.T) {
+	// Test that rotated files are compressed and the current file is not
+	for _, tt := range []struct {
+		compression Compression
+		decompress  func(r io.Reader) (io.Reader, error)
+	}{
+		{Gzip, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{Zstd, func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	} {
+		dir := t.TempDir()
+		clock := newFakeClock()
+
+		f, err := New(filepath.Join(dir, "app.log"), Options{Compress: tt.compression, Now: clock.Now})
+		if err != nil {
+			t.Fatal("Expected no error from New, got:", err)
+		}
+		_, _ = f.Write([]byte("rotated content\n"))
+		first := f.Path()
+		clock.Advance(time.Second)
+		_ = f.Rotate()
+		_, _ = f.Write([]byte("current content\n"))
+		current := f.Path()
+		_ = f.Close()
+
+		if _, err := os.Stat(first); !os.IsNotExist(err) {
+			t.Fatalf("%s: expected the uncompressed backup to be removed", tt.compression)
+		}
+		if readFile(t, current) != "current content\n" {
+			t.Fatalf("%s: expected the current file to stay uncompressed", tt.compression)
+		}
+
+		compressed, err := os.Open(first + tt.compression.ext())
+		if err != nil {
+			t.Fatalf("%s: expected compressed backup, got %v", tt.compression, err)
+		}
+		r, err := tt.decompress(compressed)
+		if err != nil {
+			t.Fatalf("%s: expected valid compressed data, got %v", tt.compression, err)
+		}
+		data, err := io.ReadAll(r)
+		_ = compressed.Close()
+		if err != nil || string(data) != "rotated content\n" {
+			t.Fatalf("%s: unexpected decompressed content %q (err=%v)", tt.compression, data, err)
+		}
+
+		for _, name := range logFiles(t, dir) {
+			if strings.HasSuffix(name, ".tmp") {
+				t.Fatalf("%s: unexpected temporary file %s", tt.compression, name)
+			}
+		}
+	}
+}
+
+func TestRetentionCountsCompressedFiles(t *testing.T) {
+	// Test that compressed backups are still subject to MaxBackups
+	dir := t.TempDir()
+	clock := newFakeClock()
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{Compress: Gzip, MaxBackups: 1, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = f.Write([]byte(fmt.Sprintf("file %d\n", i)))
+		clock.Advance(time.Second)
+		_ = f.Rotate()
+	}
+	_ = f.Close()
+
+	files := logFiles(t, dir)
+	expected := []string{
+		"app-2023-01-01T10-30-02.000.log.gz",
+		"app-2023-01-01T10-30-03.000.log",
+	}
+	if fmt.Sprint(files) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v, got %v", expected, files)
+	}
+}
+
+func TestMaxTotalSize(t *testing.T) {
+	// Test that the oldest backups are removed to stay within the disk budget
+	dir := t.TempDir()
+	clock := newFakeClock()
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{MaxTotalSize: 25, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	for i := 0; i < 4; i++ {
+		_, _ = f.Write([]byte("0123456789"))
+		clock.Advance(time.Second)
+		_ = f.Rotate()
+	}
+	_ = f.Close()
+
+	files := logFiles(t, dir)
+	expected := []string{
+		"app-2023-01-01T10-30-02.000.log",
+		"app-2023-01-01T10-30-03.000.log",
+		"app-2023-01-01T10-30-04.000.log",
+	}
+	if fmt.Sprint(files) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v, got %v", expected, files)
+	}
+}
+
+func TestCleanupOnStart(t *testing.T) {
+	// Test that files left by a previous run are processed
+	dir := t.TempDir()
+	clock := newFakeClock()
+	name := filepath.Join(dir, "app.log")
+
+	f, err := New(name, Options{Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	old := f.Path()
+	_ = f.Close()
+
+	clock.Advance(time.Hour)
+	f, err = New(name, Options{Interval: Hourly, Compress: Gzip, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	_ = f.Close()
+
+	if _, err := os.Stat(old + ".gz"); err != nil {
+		t.Fatal("Expected leftover file to be compressed, got:", err)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	// Test that errors without a caller are reported through OnError
+	dir := t.TempDir()
+	clock := newFakeClock()
+	linkDir := filepath.Join(dir, "links")
+	if err := os.Mkdir(linkDir, 0o755); err != nil {
+		t.Fatal("Expected no error creating dir, got:", err)
+	}
+
+	var mu sync.Mutex
+	var reported []error
+	f, err := New(filepath.Join(dir, "app.log"), Options{
+		MaxSize: 5,
+		Symlink: filepath.Join(linkDir, "current.log"),
+		Now:     clock.Now,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, err)
+		},
+	})
+	if err != nil {
+		t.Skip("Symlinks not supported:", err)
+	}
+	defer f.Close()
+
+	// Break the symlink location, then trigger an automatic rotation
+	if err := os.RemoveAll(linkDir); err != nil {
+		t.Fatal("Expected no error removing dir, got:", err)
+	}
+	_, _ = f.Write([]byte("12345"))
+	clock.Advance(time.Second)
+	if _, err := f.Write([]byte("67890")); err != nil {
+		t.Fatal("Expected the write to succeed despite the symlink error, got:", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || !errors.Is(reported[0], os.ErrNotExist) {
+		t.Fatalf("Expected one symlink error, got %v", reported)
+	}
+}
+
+func TestInvalidCompression(t *testing.T) {
+	// Test that unknown compressions are rejected
+	if _, err := New(filepath.Join(t.TempDir(), "app.log"), Options{Compress: Compression(42)}); err == nil {
+		t.Fatal("Expected error for unknown compression")
+	}
+}
@@ -0,0 +1,375 @@
+// Package rotate provides an io.WriteCloser that writes to a file and rotates it
+// by size and/or time interval, for use with logx.Logger.SetOutput
+//
+// The current file is named after the configured filename with the time it was
+// opened inserted before the extension, e.g. "logs/app.log" is written as
+// "logs/app-2006-01-02T15-04-05.000.log". An optional symlink always points at
+// the current file, so tools can keep following "logs/current.log".
+//
+// Rotated files can be compressed with gzip or zstd and are removed by count,
+// age and total disk budget in a background goroutine, which Close stops.
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeFormat is the timestamp inserted into file names, it sorts lexically and avoids ':' for Windows
+const timeFormat = "2006-01-02T15-04-05.000"
+
+// Interval is a time based rotation period
+type Interval int
+
+const (
+	Never  Interval = iota // No time based rotation
+	Hourly                 // Rotate at the start of every hour
+	Daily                  // Rotate at midnight
+)
+
+// String returns the string representation of the interval
+func (i Interval) String() string {
+	switch i {
+	case Hourly:
+		return "hourly"
+	case Daily:
+		return "daily"
+	default:
+		return "never"
+	}
+}
+
+// next returns the first period boundary after t, in t's location
+func (i Interval) next(t time.Time) time.Time {
+	switch i {
+	case Hourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case Daily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// Options configures a RotatingFile
+// Zero values disable the corresponding feature, except Now which defaults to time.Now
+type Options struct {
+	MaxSize      int64            // Rotate before the current file would exceed this many bytes
+	Interval     Interval         // Rotate at hour or day boundaries
+	MaxBackups   int              // Keep at most this many rotated files
+	MaxAge       time.Duration    // Remove rotated files older than this, judged by the time in their name
+	MaxTotalSize int64            // Remove the oldest rotated files once all of them together exceed this many bytes
+	Compress     Compression      // Compress rotated files in the background
+	Symlink      string           // Path of a symlink kept pointing at the current file
+	Now          func() time.Time // Clock used for file names and intervals, default time.Now
+	OnError      func(err error)  // Called with errors from background maintenance and automatic rotation, which have no caller to return them to
+}
+
+// RotatingFile is an io.WriteCloser writing to a rotating log file
+// It is safe for concurrent use
+type RotatingFile struct {
+	mu       sync.Mutex
+	filename string    // Configured file name, used as the pattern for real file names
+	opts     Options   // Rotation options
+	file     *os.File  // Current file, nil until opened or after Close
+	path     string    // Path of the current file
+	size     int64     // Bytes written to the current file
+	deadline time.Time // Next interval boundary, zero when time based rotation is disabled
+
+	wake chan struct{} // Signals the maintenance goroutine that a file was rotated
+	quit chan struct{} // Closed by Close to stop the maintenance goroutine
+	done chan struct{} // Closed when the maintenance goroutine has exited
+	stop sync.Once     // Closes quit once, however often Close is called
+}
+
+// New opens a RotatingFile for filename, creating its directory if needed
+// If the newest existing file is still within the current interval and below
+// MaxSize, writing resumes in it instead of starting a new file
+func New(filename string, opts Options) (*RotatingFile, error) {
+	if filename == "" {
+		return nil, errors.New("rotate: empty filename")
+	}
+	if opts.MaxSize < 0 || opts.MaxBackups < 0 || opts.MaxAge < 0 || opts.MaxTotalSize < 0 {
+		return nil, errors.New("rotate: negative limit in options")
+	}
+	if opts.Compress < NoCompression || opts.Compress > Zstd {
+		return nil, fmt.Errorf("rotate: unknown compression %d", opts.Compress)
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	f := &RotatingFile{filename: filename, opts: opts}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return nil, err
+	}
+	if err := f.resume(); err != nil {
+		return nil, err
+	}
+	if f.file == nil {
+		if err := f.openNew(); err != nil {
+			return nil, err
+		}
+	}
+	if err := f.link(); err != nil {
+		_ = f.file.Close()
+		return nil, err
+	}
+	if f.needsMaintenance() {
+		f.wake = make(chan struct{}, 1)
+		f.quit = make(chan struct{})
+		f.done = make(chan struct{})
+		go f.maintain()
+		// Pick up files left over from previous runs
+		f.notify()
+	}
+	return f, nil
+}
+
+// Write writes p to the current file, rotating first if p would exceed MaxSize
+// or the current interval has ended
+// If the new file cannot be opened, the error is passed to OnError and p is written
+// to the current file, the rotation is tried again on the next Write
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.shouldRotate(int64(len(p))) {
+		f.report(f.rotate())
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and starts a new one
+// If the new file cannot be opened, the current file is kept and the error returned
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	if err := f.rotate(); err != nil {
+		return err
+	}
+	return f.link()
+}
+
+// Sync commits the current file's contents to stable storage
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.file.Sync()
+}
+
+// Close closes the current file, later writes return os.ErrClosed
+// It waits for the maintenance goroutine to finish a last pass over the rotated files
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+	f.stop.Do(func() {
+		if f.quit != nil {
+			close(f.quit)
+			<-f.done
+		}
+	})
+	return err
+}
+
+// Path returns the path of the file currently written to
+func (f *RotatingFile) Path() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.path
+}
+
+// shouldRotate reports whether writing n more bytes requires a new file
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+	return !f.deadline.IsZero() && !f.opts.Now().Before(f.deadline)
+}
+
+// rotate replaces the current file with a new one and hands the old one to the maintenance goroutine
+// The new file is opened first, so the current one stays in use if that fails
+// Errors closing the old file and symlink errors are reported through OnError so
+// that they never block writes
+func (f *RotatingFile) rotate() error {
+	old := f.file
+	if err := f.openNew(); err != nil {
+		return err
+	}
+	f.report(old.Close())
+	if err := f.link(); err != nil {
+		f.report(err)
+	}
+	f.notify()
+	return nil
+}
+
+// openNew creates a new file named after the current time and makes it current
+// The current file is left unchanged on error
+func (f *RotatingFile) openNew() error {
+	now := f.opts.Now()
+	path := f.nameFor(now, 0)
+	// Several rotations within the same millisecond get a sequence number
+	for seq := 1; ; seq++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			break
+		}
+		path = f.nameFor(now, seq)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	f.file, f.path, f.size = file, path, 0
+	f.deadline = f.opts.Interval.next(now)
+	return nil
+}
+
+// resume opens the newest existing file for appending if it can still be written to
+func (f *RotatingFile) resume() error {
+	files, err := f.list()
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	newest := files[len(files)-1]
+	now := f.opts.Now()
+	if f.opts.Interval != Never && !now.Before(f.opts.Interval.next(newest.time.In(now.Location()))) {
+		return nil
+	}
+	info, err := os.Stat(newest.path)
+	if err != nil {
+		return nil
+	}
+	if f.opts.MaxSize > 0 && info.Size() >= f.opts.MaxSize {
+		return nil
+	}
+	file, err := os.OpenFile(newest.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil
+	}
+	f.file, f.path, f.size = file, newest.path, info.Size()
+	f.deadline = f.opts.Interval.next(newest.time.In(now.Location()))
+	return nil
+}
+
+// link points the symlink at the current file
+// The link is replaced atomically by renaming a temporary link over it
+func (f *RotatingFile) link() error {
+	if f.opts.Symlink == "" {
+		return nil
+	}
+	target := f.path
+	if filepath.Dir(f.opts.Symlink) == filepath.Dir(f.path) {
+		target = filepath.Base(f.path)
+	}
+	tmp := f.opts.Symlink + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("rotate: create symlink: %w", err)
+	}
+	if err := os.Rename(tmp, f.opts.Symlink); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rotate: replace symlink: %w", err)
+	}
+	return nil
+}
+
+// logFile is a file created by a RotatingFile
+type logFile struct {
+	path        string
+	time        time.Time   // Time parsed from the file name
+	seq         int         // Sequence number for files created within the same millisecond
+	compression Compression // Compression recognized from the file name suffix
+}
+
+// nameFor returns the file name for a file opened at t
+func (f *RotatingFile) nameFor(t time.Time, seq int) string {
+	ext := filepath.Ext(f.filename)
+	name := strings.TrimSuffix(f.filename, ext) + "-" + t.Format(timeFormat)
+	if seq > 0 {
+		name += "." + strconv.Itoa(seq)
+	}
+	return name + ext
+}
+
+// list returns the files created for filename, oldest first
+func (f *RotatingFile) list() ([]logFile, error) {
+	dir := filepath.Dir(f.filename)
+	ext := filepath.Ext(f.filename)
+	prefix := strings.TrimSuffix(filepath.Base(f.filename), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []logFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest, compression := strings.TrimPrefix(name, prefix), NoCompression
+		for _, c := range []Compression{Gzip, Zstd} {
+			if strings.HasSuffix(rest, c.ext()) {
+				rest, compression = strings.TrimSuffix(rest, c.ext()), c
+				break
+			}
+		}
+		if lf, ok := parseName(rest, ext, f.opts.Now().Location()); ok {
+			lf.path = filepath.Join(dir, name)
+			lf.compression = compression
+			files = append(files, lf)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].time.Equal(files[j].time) {
+			return files[i].time.Before(files[j].time)
+		}
+		return files[i].seq < files[j].seq
+	})
+	return files, nil
+}
+
+// parseName parses the part of a file name after the prefix, e.g. "2006-01-02T15-04-05.000.3.log"
+func parseName(rest, ext string, loc *time.Location) (logFile, bool) {
+	if !strings.HasSuffix(rest, ext) || len(rest) < len(timeFormat)+len(ext) {
+		return logFile{}, false
+	}
+	rest = strings.TrimSuffix(rest, ext)
+	t, err := time.ParseInLocation(timeFormat, rest[:len(timeFormat)], loc)
+	if err != nil {
+		return logFile{}, false
+	}
+	lf := logFile{time: t}
+	if suffix := rest[len(timeFormat):]; suffix != "" {
+		if suffix[0] != '.' {
+			return logFile{}, false
+		}
+		seq, err := strconv.Atoi(suffix[1:])
+		if err != nil || seq <= 0 {
+			return logFile{}, false
+		}
+		lf.seq = seq
+	}
+	return lf, true
+}
@@ -0,0 +1,388 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2023, 1, 1, 10, 30, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// logFiles returns the names of the log files in dir, excluding symlinks
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal("Expected no error reading dir, got:", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type()&os.ModeSymlink == 0 {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("Expected no error reading file, got:", err)
+	}
+	return string(data)
+}
+
+func TestFileNaming(t *testing.T) {
+	// Test that the current file carries the time it was opened
+	dir := t.TempDir()
+	clock := newFakeClock()
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	defer f.Close()
+
+	expected := filepath.Join(dir, "app-2023-01-01T10-30-00.000.log")
+	if f.Path() != expected {
+		t.Fatalf("Expected current file %s, got %s", expected, f.Path())
+	}
+
+	if _, err := f.Write([]byte("hello\n")); err != nil {
+		t.Fatal("Expected no error from Write, got:", err)
+	}
+	if readFile(t, expected) != "hello\n" {
+		t.Fatal("Expected content in current file")
+	}
+}
+
+func TestRotateBySize(t *testing.T) {
+	// Test rotation when a write would exceed MaxSize
+	dir := t.TempDir()
+	clock := newFakeClock()
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{MaxSize: 10, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	defer f.Close()
+
+	first := f.Path()
+	_, _ = f.Write([]byte("12345"))
+	_, _ = f.Write([]byte("67890"))
+	if f.Path() != first {
+		t.Fatal("Expected no rotation while within MaxSize")
+	}
+
+	// Same millisecond: the new file gets a sequence number
+	_, _ = f.Write([]byte("abc"))
+	if f.Path() == first {
+		t.Fatal("Expected rotation after exceeding MaxSize")
+	}
+	if !strings.HasSuffix(f.Path(), "app-2023-01-01T10-30-00.000.1.log") {
+		t.Fatalf("Expected sequence numbered file, got %s", f.Path())
+	}
+
+	if readFile(t, first) != "1234567890" || readFile(t, f.Path()) != "abc" {
+		t.Fatal("Unexpected file contents after rotation")
+	}
+
+	// A single write larger than MaxSize still goes to a file
+	clock.Advance(time.Second)
+	if _, err := f.Write([]byte(strings.Repeat("x", 20))); err != nil {
+		t.Fatal("Expected no error for oversized write, got:", err)
+	}
+	if len(logFiles(t, dir)) != 3 {
+		t.Fatalf("Expected 3 files, got %v", logFiles(t, dir))
+	}
+}
+
+func TestRotateByInterval(t *testing.T) {
+	// Test hourly and daily rotation boundaries
+	for _, tt := range []struct {
+		interval Interval
+		advance  time.Duration
+		expected string
+	}{
+		{Hourly, 30 * time.Minute, "app-2023-01-01T11-00-00.000.log"},
+		{Daily, 13*time.Hour + 30*time.Minute, "app-2023-01-02T00-00-00.000.log"},
+	} {
+		dir := t.TempDir()
+		clock := newFakeClock()
+
+		f, err := New(filepath.Join(dir, "app.log"), Options{Interval: tt.interval, Now: clock.Now})
+		if err != nil {
+			t.Fatal("Expected no error from New, got:", err)
+		}
+		first := f.Path()
+
+		clock.Advance(tt.advance - time.Millisecond)
+		_, _ = f.Write([]byte("before\n"))
+		if f.Path() != first {
+			t.Fatalf("%s: expected no rotation before the boundary", tt.interval)
+		}
+
+		clock.Advance(time.Millisecond)
+		_, _ = f.Write([]byte("after\n"))
+		if filepath.Base(f.Path()) != tt.expected {
+			t.Fatalf("%s: expected %s after the boundary, got %s", tt.interval, tt.expected, f.Path())
+		}
+		_ = f.Close()
+	}
+}
+
+func TestMaxBackups(t *testing.T) {
+	// Test that only the newest backups are kept
+	dir := t.TempDir()
+	clock := newFakeClock()
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{MaxBackups: 2, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		clock.Advance(time.Second)
+		if err := f.Rotate(); err != nil {
+			t.Fatal("Expected no error from Rotate, got:", err)
+		}
+	}
+	// Close waits for the background maintenance to finish
+	_ = f.Close()
+
+	files := logFiles(t, dir)
+	expected := []string{
+		"app-2023-01-01T10-30-03.000.log",
+		"app-2023-01-01T10-30-04.000.log",
+		"app-2023-01-01T10-30-05.000.log",
+	}
+	if fmt.Sprint(files) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v, got %v", expected, files)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	// Test that old backups are removed
+	dir := t.TempDir()
+	clock := newFakeClock()
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{MaxAge: time.Hour, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+
+	clock.Advance(30 * time.Minute)
+	_ = f.Rotate()
+	clock.Advance(45 * time.Minute)
+	_ = f.Rotate()
+	_ = f.Close()
+
+	files := logFiles(t, dir)
+	expected := []string{
+		"app-2023-01-01T11-00-00.000.log",
+		"app-2023-01-01T11-45-00.000.log",
+	}
+	if fmt.Sprint(files) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v, got %v", expected, files)
+	}
+}
+
+func TestSymlink(t *testing.T) {
+	// Test that the symlink follows the current file
+	dir := t.TempDir()
+	clock := newFakeClock()
+	link := filepath.Join(dir, "current.log")
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{Symlink: link, Now: clock.Now})
+	if err != nil {
+		t.Skip("Symlinks not supported:", err)
+	}
+	defer f.Close()
+
+	_, _ = f.Write([]byte("first\n"))
+	clock.Advance(time.Second)
+	_ = f.Rotate()
+	_, _ = f.Write([]byte("second\n"))
+
+	if readFile(t, link) != "second\n" {
+		t.Fatal("Expected symlink to point at the current file")
+	}
+	target, err := os.Readlink(link)
+	if err != nil || target != filepath.Base(f.Path()) {
+		t.Fatalf("Expected relative link to %s, got %s (err=%v)", filepath.Base(f.Path()), target, err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	// Test that reopening appends to the newest file within the interval
+	dir := t.TempDir()
+	clock := newFakeClock()
+	name := filepath.Join(dir, "app.log")
+
+	f, err := New(name, Options{Interval: Hourly, MaxSize: 100, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	_, _ = f.Write([]byte("one\n"))
+	first := f.Path()
+	_ = f.Close()
+
+	clock.Advance(time.Minute)
+	f, err = New(name, Options{Interval: Hourly, MaxSize: 100, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	_, _ = f.Write([]byte("two\n"))
+	if f.Path() != first || readFile(t, first) != "one\ntwo\n" {
+		t.Fatal("Expected to resume writing in the newest file")
+	}
+	_ = f.Close()
+
+	// After the interval a new file is started
+	clock.Advance(time.Hour)
+	f, err = New(name, Options{Interval: Hourly, MaxSize: 100, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	defer f.Close()
+	if f.Path() == first {
+		t.Fatal("Expected a new file after the interval ended")
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	// Test that writes fail after Close
+	f, err := New(filepath.Join(t.TempDir(), "app.log"), Options{})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal("Expected no error from Close, got:", err)
+	}
+	if _, err := f.Write([]byte("late")); err != os.ErrClosed {
+		t.Fatalf("Expected os.ErrClosed, got %v", err)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	// Test that concurrent writes are neither lost nor interleaved
+	dir := t.TempDir()
+	clock := newFakeClock()
+
+	f, err := New(filepath.Join(dir, "app.log"), Options{MaxSize: 4096, Now: clock.Now})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+
+	const goroutines = 20
+	const writes = 200
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				_, _ = fmt.Fprintf(f, "goroutine %02d line %03d\n", id, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	_ = f.Close()
+
+	total := 0
+	for _, name := range logFiles(t, dir) {
+		for _, line := range strings.Split(strings.TrimSuffix(readFile(t, filepath.Join(dir, name)), "\n"), "\n") {
+			if !strings.HasPrefix(line, "goroutine ") || len(line) != len("goroutine 00 line 000") {
+				t.Fatalf("Unexpected line %q in %s", line, name)
+			}
+			total++
+		}
+	}
+	if total != goroutines*writes {
+		t.Fatalf("Expected %d lines, got %d", goroutines*writes, total)
+	}
+}
+
+func TestRotateFailureKeepsFile(t *testing.T) {
+	// Test that a failed rotation keeps writing to the current file and recovers once the cause is gone
+	dir := filepath.Join(t.TempDir(), "logs")
+	clock := newFakeClock()
+	var mu sync.Mutex
+	var errs []error
+	f, err := New(filepath.Join(dir, "app.log"), Options{MaxSize: 10, MaxBackups: 5, Now: clock.Now, OnError: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}})
+	if err != nil {
+		t.Fatal("Expected no error from New, got:", err)
+	}
+	first := f.Path()
+	_, _ = f.Write([]byte("1234567890"))
+
+	// The new file cannot be created while the directory is missing
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("kept")); err != nil {
+		t.Fatal("Expected the write to go to the current file, got:", err)
+	}
+	if err := f.Rotate(); err == nil {
+		t.Fatal("Expected Rotate to return the error")
+	}
+	mu.Lock()
+	if len(errs) == 0 || f.Path() != first {
+		t.Fatalf("Expected a reported error and the current file kept, got %v and %s", errs, f.Path())
+	}
+	mu.Unlock()
+
+	// Rotation succeeds again once the directory is back
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Second)
+	if _, err := f.Write([]byte("recovered")); err != nil {
+		t.Fatal("Expected no error after recovery, got:", err)
+	}
+	if f.Path() == first || readFile(t, f.Path()) != "recovered" {
+		t.Fatalf("Expected a new file after recovery, got %s", f.Path())
+	}
+
+	// Close stops the maintenance goroutine, and can be called again
+	if err := f.Close(); err != nil {
+		t.Fatal("Expected no error from Close, got:", err)
+	}
+	select {
+	case <-f.done:
+	default:
+		t.Fatal("Expected the maintenance goroutine to have exited")
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal("Expected no error from a second Close, got:", err)
+	}
+}
@@ -0,0 +1,89 @@
+package logx
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// raise delivers sig to the current process, replaced in tests
+var raise = func(sig os.Signal) error {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
+
+// exit terminates the process, replaced in tests
+var exit = os.Exit
+
+// FlushOnSignal syncs the global Logger when the process receives one of sigs, SIGINT
+// and SIGTERM by default, so buffered entries are not lost, and then ends the process as
+// the signal would have without logx
+// To do so it resets the handling of sigs for the whole process, which removes every
+// other signal.Notify and signal.NotifyContext registration for them, and delivers the
+// signal again, falling back to exiting with status 128+signal
+// It is meant for programs that do not handle the signals themselves, programs that
+// shut down gracefully should use SyncOnSignal instead
+// The returned function stops watching the signals
+func FlushOnSignal(sigs ...os.Signal) (stop func()) {
+	return watchSignals(sigs, true)
+}
+
+// SyncOnSignal syncs the global Logger every time the process receives one of sigs,
+// SIGINT and SIGTERM by default, and leaves exiting to the application: other
+// signal.Notify and signal.NotifyContext registrations still receive the signals, so a
+// graceful shutdown proceeds as before and should end with Sync or Close to write the
+// entries logged while shutting down
+// Like any signal.Notify registration it stops the signals from terminating the process
+// by default, so it must only be used along with the application's own handling
+// The returned function stops watching the signals
+func SyncOnSignal(sigs ...os.Signal) (stop func()) {
+	return watchSignals(sigs, false)
+}
+
+// watchSignals syncs the global Logger on sigs until stopped, and with exitAfter ends
+// the process after the first one
+func watchSignals(sigs []os.Signal, exitAfter bool) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	quit := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				_ = Sync()
+				if !exitAfter {
+					continue
+				}
+				signal.Reset(sigs...)
+				if err := raise(sig); err != nil {
+					exit(exitCode(sig))
+				}
+				return
+			case <-quit:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(quit)
+		})
+	}
+}
+
+// exitCode returns the conventional shell exit status for a process killed by sig
+func exitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
@@ -0,0 +1,110 @@
+//go:build !windows
+// +build !windows
+
+package logx
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSyncOnSignal(t *testing.T) {
+	// Test that the global Logger is synced on every signal, leaving the signal to the application
+	w := &lifecycleWriter{}
+	std = nil
+	stdOnce = sync.Once{}
+	SetOutput(w)
+
+	defer func(r func(os.Signal) error, e func(int)) { raise, exit = r, e }(raise, exit)
+	raise = func(os.Signal) error {
+		t.Error("Expected the signal not to be raised again")
+		return nil
+	}
+	exit = func(int) { t.Error("Expected the process not to exit") }
+
+	// The application's own handler keeps receiving the signal
+	app := make(chan os.Signal, 2)
+	signal.Notify(app, syscall.SIGUSR1)
+	defer signal.Stop(app)
+
+	stop := SyncOnSignal(syscall.SIGUSR1)
+	defer stop()
+	for i := 0; i < 2; i++ {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-app:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the application to receive the signal")
+		}
+	}
+	// Syncing happens on another goroutine
+	deadline := time.Now().Add(5 * time.Second)
+	for w.count("flush") < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if w.count("flush") != 2 {
+		t.Fatal("Expected the global Logger to be synced twice, got:", w.snapshot())
+	}
+}
+
+func TestFlushOnSignal(t *testing.T) {
+	// Test that the global Logger is synced before the signal is delivered again
+	w := &lifecycleWriter{}
+	std = nil
+	stdOnce = sync.Once{}
+	SetOutput(w)
+
+	raised := make(chan os.Signal, 1)
+	defer func(r func(os.Signal) error) { raise = r }(raise)
+	raise = func(sig os.Signal) error {
+		raised <- sig
+		return nil
+	}
+
+	stop := FlushOnSignal(syscall.SIGUSR1)
+	defer stop()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case sig := <-raised:
+		if sig != syscall.SIGUSR1 {
+			t.Fatal("Expected SIGUSR1 to be raised again, got:", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the signal to be handled")
+	}
+	if calls := w.snapshot(); len(calls) == 0 || calls[0] != "flush" {
+		t.Fatal("Expected the global Logger to be synced, got:", calls)
+	}
+}
+
+func TestFlushOnSignalExit(t *testing.T) {
+	// Test falling back to exiting when the signal cannot be delivered again
+	std = nil
+	stdOnce = sync.Once{}
+	SetOutput(&lifecycleWriter{})
+
+	codes := make(chan int, 1)
+	defer func(r func(os.Signal) error, e func(int)) { raise, exit = r, e }(raise, exit)
+	raise = func(os.Signal) error { return os.ErrProcessDone }
+	exit = func(code int) { codes <- code }
+
+	stop := FlushOnSignal(syscall.SIGUSR2)
+	defer stop()
+	_ = syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	select {
+	case code := <-codes:
+		if code != 128+int(syscall.SIGUSR2) {
+			t.Fatalf("Expected exit status %d, got %d", 128+int(syscall.SIGUSR2), code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the process to exit")
+	}
+}
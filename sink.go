@@ -0,0 +1,152 @@
+package logx
+
+import (
+	"io"
+	"os"
+	"strings"
+	"unsafe"
+)
+
+// Sink is an additional output of a Logger with its own writer, formatter and minimum level
+// e.g. JSON to a file, colored text to stderr and only errors to an alerting writer
+type Sink struct {
+	writer    io.Writer // Output destination
+	formatter Formatter // Formatting function
+	level     Level     // Minimum level written to this sink
+	color     bool      // Whether entries may be colored, decided from writer as with ColorAuto
+}
+
+// NewSink returns a Sink writing entries at or above level to w, formatted by fn
+// DefaultFormatter is used if fn is nil, and colors are decided from w as with ColorAuto
+func NewSink(w io.Writer, fn Formatter, level Level) *Sink {
+	if fn == nil {
+		fn = DefaultFormatter
+	}
+	return &Sink{writer: w, formatter: fn, level: level, color: useColor(ColorAuto, w)}
+}
+
+// Writer returns the output destination of the sink
+func (s *Sink) Writer() io.Writer {
+	return s.writer
+}
+
+// Level returns the minimum level written to the sink
+func (s *Sink) Level() Level {
+	return s.level
+}
+
+// AddSink adds an output to the Logger (thread-safe)
+// Entries must pass the Logger's own level first, so a sink only ever narrows it further
+// The change is visible to all loggers sharing the same output state
+func (l *Logger) AddSink(s *Sink) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	// Copy on write so log calls can use the slice without holding the lock
+	sinks := make([]*Sink, 0, len(l.core.sinks)+1)
+	l.core.sinks = append(append(sinks, l.core.sinks...), s)
+}
+
+// SetSinks replaces all outputs added with AddSink (thread-safe)
+// The writer set with SetOutput is not affected
+func (l *Logger) SetSinks(sinks ...*Sink) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.own |= ownSinks
+	l.core.sinks = append([]*Sink(nil), sinks...)
+}
+
+// formatterKey identifies a Formatter value
+// Func values are not comparable, but two copies of the same func value share the
+// pointer to its closure, while different closures from the same constructor do not
+func formatterKey(fn Formatter) uintptr {
+	return *(*uintptr)(unsafe.Pointer(&fn))
+}
+
+// formatted is an entry already rendered by a formatter
+type formatted struct {
+	key   uintptr // formatterKey of the formatter
+	color bool    // Color setting the entry was rendered with
+	data  []byte
+}
+
+// fanOut writes entry to every sink accepting its level
+// Each distinct formatter and color combination renders the entry at most once, done
+// holds the renderings already made for the primary writer
+// A failing sink does not prevent writing to the others, all errors are returned together
+func fanOut(entry LogEntry, sinks []*Sink, done []formatted) error {
+	var errs []error
+	for _, s := range sinks {
+		if entry.Level < s.level {
+			continue
+		}
+		key := formatterKey(s.formatter)
+		var data []byte
+		for _, f := range done {
+			if f.key == key && f.color == s.color {
+				data = f.data
+				break
+			}
+		}
+		if data == nil {
+			entry.Color = s.color
+			data = s.formatter(entry)
+			done = append(done, formatted{key: key, color: s.color, data: data})
+		}
+		if err := writeLevel(s.writer, entry.Level, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// writeLevel writes data to w, letting level-aware writers such as AsyncWriter see the level
+// A nil writer writes to os.Stdout
+func writeLevel(w io.Writer, level Level, data []byte) error {
+	if w == nil {
+		w = os.Stdout
+	}
+	if lw, ok := w.(LevelWriter); ok {
+		_, err := lw.WriteLevel(level, data)
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// multiError holds the errors of several independent operations
+type multiError []error
+
+// Error joins the messages of all errors
+func (e multiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors for errors.Is and errors.As
+func (e multiError) Unwrap() []error {
+	return e
+}
+
+// joinErrors returns nil if all errs are nil, the only error if there is one,
+// and a flat multiError otherwise
+func joinErrors(errs ...error) error {
+	var joined multiError
+	for _, err := range errs {
+		if m, ok := err.(multiError); ok {
+			joined = append(joined, m...)
+		} else if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	switch len(joined) {
+	case 0:
+		return nil
+	case 1:
+		return joined[0]
+	default:
+		return joined
+	}
+}
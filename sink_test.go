@@ -0,0 +1,94 @@
+package logx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSinks(t *testing.T) {
+	// Test that every sink receives the entries its level admits, in its own format
+	var primary, jsonOut, alerts bytes.Buffer
+	logger := New(&primary)
+	logger.SetFormatter(func(entry LogEntry) []byte { return []byte("text:" + entry.Message + "\n") })
+	logger.AddSink(NewSink(&jsonOut, JSONFormatter(JSONOptions{}), LevelInfo))
+	logger.AddSink(NewSink(&alerts, func(entry LogEntry) []byte { return []byte("alert:" + entry.Message + "\n") }, LevelError))
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Error("error")
+
+	if primary.String() != "text:debug\ntext:info\ntext:error\n" {
+		t.Fatal("Unexpected primary output:", primary.String())
+	}
+	if strings.Count(jsonOut.String(), "\n") != 2 || strings.Contains(jsonOut.String(), `"debug"`) ||
+		!strings.Contains(jsonOut.String(), `"message":"info"`) {
+		t.Fatal("Unexpected JSON sink output:", jsonOut.String())
+	}
+	if alerts.String() != "alert:error\n" {
+		t.Fatal("Unexpected alert sink output:", alerts.String())
+	}
+
+	// The Logger's own level applies to sinks too
+	logger.SetLevel(LevelWarn)
+	logger.Info("filtered")
+	if strings.Contains(jsonOut.String(), "filtered") {
+		t.Fatal("Expected the Logger level to filter sinks, got:", jsonOut.String())
+	}
+
+	// SetSinks replaces the added sinks but keeps the primary writer
+	logger.SetSinks()
+	logger.Error("after reset")
+	if strings.Contains(alerts.String(), "after reset") || !strings.Contains(primary.String(), "text:after reset") {
+		t.Fatal("Expected SetSinks to remove the sinks only")
+	}
+}
+
+func TestSinksFormatOnce(t *testing.T) {
+	// Test that an entry is formatted once per distinct formatter
+	var calls int32
+	counting := func(entry LogEntry) []byte {
+		atomic.AddInt32(&calls, 1)
+		return []byte(entry.Message + "\n")
+	}
+	var a, b, c bytes.Buffer
+	logger := New(&a)
+	logger.SetColor(ColorNever)
+	logger.SetFormatter(counting)
+	logger.AddSink(NewSink(&b, counting, LevelDebug))
+	logger.AddSink(NewSink(&c, counting, LevelDebug))
+
+	logger.Info("shared")
+	if calls != 1 {
+		t.Fatalf("Expected 1 formatter call for 3 outputs, got %d", calls)
+	}
+	if a.String() != "shared\n" || b.String() != "shared\n" || c.String() != "shared\n" {
+		t.Fatal("Expected identical output on all writers")
+	}
+}
+
+func TestSinkFailure(t *testing.T) {
+	// Test that a failing sink neither blocks the others nor hides its error
+	var before, after bytes.Buffer
+	logger := New(&before)
+	logger.AddSink(NewSink(errorWriter{}, nil, LevelDebug))
+	logger.AddSink(NewSink(&after, nil, LevelDebug))
+
+	err := logger.Log(LevelInfo, "still written")
+	if err == nil || !strings.Contains(err.Error(), "write failed") {
+		t.Fatal("Expected the sink error to be returned, got:", err)
+	}
+	if !strings.Contains(before.String(), "still written") || !strings.Contains(after.String(), "still written") {
+		t.Fatal("Expected the other outputs to be written")
+	}
+
+	// Errors from the primary writer and sinks are combined
+	logger.SetOutput(errorWriter{})
+	err = logger.Log(LevelInfo, "x")
+	var multi multiError
+	if !errors.As(err, &multi) || len(multi) != 2 {
+		t.Fatalf("Expected 2 combined errors, got %v", err)
+	}
+}
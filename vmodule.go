@@ -0,0 +1,229 @@
+package logx
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxCachedPrefixes bounds the prefixes whose rule decisions are cached, prefixes may be
+// made per request with WithPrefix, unlike call sites which are bounded by the program
+const maxCachedPrefixes = 1024
+
+// vmodule holds parsed per-module level rules and the decisions made for each call site
+// It is immutable apart from the caches, SetVModule replaces it as a whole
+type vmodule struct {
+	spec      string        // Spec the rules were parsed from
+	rules     []vmoduleRule // Rules in spec order, the first match wins
+	min, max  Level         // Lowest and highest rule level, to decide most calls without a lookup
+	files     sync.Map      // Call site pc -> index of the first rule matching its file, len(rules) if none
+	prefixes  sync.Map      // Prefix -> index of the first rule matching it, len(rules) if none
+	nprefixes int32         // Number of cached prefixes, accessed atomically
+}
+
+// vmoduleRule is a single pattern=level rule
+type vmoduleRule struct {
+	pattern string
+	level   Level
+}
+
+// vmoduleDecision is the outcome of matching the rules for a call site
+type vmoduleDecision struct {
+	level   Level // Minimum level of the matching rule
+	matched bool  // Whether any rule matched, otherwise the Logger's level applies
+}
+
+// parseVModule parses a comma separated list of pattern=level rules
+// e.g. "db=debug,http/*=warn,payments.go=trace"
+func parseVModule(spec string) (*vmodule, error) {
+	vm := &vmodule{spec: spec}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		i := strings.LastIndex(part, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("logx: invalid vmodule rule %q, expected pattern=level", part)
+		}
+		pattern := strings.TrimSpace(part[:i])
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("logx: invalid vmodule pattern %q: %w", pattern, err)
+		}
+		level, err := ParseLevel(part[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		if len(vm.rules) == 0 || level < vm.min {
+			vm.min = level
+		}
+		if len(vm.rules) == 0 || level > vm.max {
+			vm.max = level
+		}
+		vm.rules = append(vm.rules, vmoduleRule{pattern: pattern, level: level})
+	}
+	if len(vm.rules) == 0 {
+		return nil, nil
+	}
+	return vm, nil
+}
+
+// decide returns the decision for a call site logging through a Logger with prefix
+// The first rule matching either the call site's file or the prefix applies, the
+// matches of both are cached separately
+func (vm *vmodule) decide(pc uintptr, prefix string) vmoduleDecision {
+	i := vm.fileRule(pc)
+	if prefix != "" {
+		if j := vm.prefixRule(prefix); j < i {
+			i = j
+		}
+	}
+	if i == len(vm.rules) {
+		return vmoduleDecision{}
+	}
+	return vmoduleDecision{level: vm.rules[i].level, matched: true}
+}
+
+// fileRule returns the index of the first rule matching the file of the call site pc
+func (vm *vmodule) fileRule(pc uintptr) int {
+	if i, ok := vm.files.Load(pc); ok {
+		return i.(int)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	i := len(vm.rules)
+	for j, r := range vm.rules {
+		if r.matchFile(frame.File) {
+			i = j
+			break
+		}
+	}
+	vm.files.Store(pc, i)
+	return i
+}
+
+// prefixRule returns the index of the first rule matching prefix
+func (vm *vmodule) prefixRule(prefix string) int {
+	if i, ok := vm.prefixes.Load(prefix); ok {
+		return i.(int)
+	}
+	i := len(vm.rules)
+	for j, r := range vm.rules {
+		if matchPattern(r.pattern, prefix) {
+			i = j
+			break
+		}
+	}
+	// Reserve a slot without counting past the cap, so the counter cannot wrap around
+	for n := atomic.LoadInt32(&vm.nprefixes); n < maxCachedPrefixes; n = atomic.LoadInt32(&vm.nprefixes) {
+		if atomic.CompareAndSwapInt32(&vm.nprefixes, n, n+1) {
+			vm.prefixes.Store(prefix, i)
+			break
+		}
+	}
+	return i
+}
+
+// matchFile reports whether the rule applies to a caller file
+// The pattern is matched with path.Match against as many trailing elements of the
+// file path as it has itself, with and without the ".go" extension and of the file's
+// directory, so "db" matches ".../db/conn.go", "http/*" matches ".../http/server.go"
+// and "payments.go" matches ".../payments.go"
+func (r vmoduleRule) matchFile(file string) bool {
+	if file == "" {
+		return false
+	}
+	n := strings.Count(r.pattern, "/") + 1
+	return matchPattern(r.pattern, trailingElems(file, n)) ||
+		matchPattern(r.pattern, trailingElems(strings.TrimSuffix(file, ".go"), n)) ||
+		matchPattern(r.pattern, trailingElems(path.Dir(file), n))
+}
+
+// matchPattern reports whether name matches the shell pattern, invalid patterns never match
+func matchPattern(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// trailingElems returns the last n slash separated elements of p
+func trailingElems(p string, n int) string {
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i] == '/' {
+			if n--; n == 0 {
+				return p[i+1:]
+			}
+		}
+	}
+	return p
+}
+
+// SetVModule sets per-module minimum levels that override the Logger's level (thread-safe)
+// spec is a comma separated list of pattern=level rules, e.g. "db=debug,http/*=warn,payments.go=trace"
+// A pattern matches a Logger prefix or the caller's file or package directory, see
+// path.Match for the pattern syntax, and the first matching rule wins
+// Rule decisions are cached per call site, an empty spec removes all rules, so a
+// named Logger uses those of its ancestors again
+// The change is visible to all loggers sharing the same output state
+func (l *Logger) SetVModule(spec string) error {
+	vm, err := parseVModule(spec)
+	if err != nil {
+		return err
+	}
+	l.core.vmodule.Store(vm)
+	return nil
+}
+
+// VModule returns the rules set with SetVModule
+func (l *Logger) VModule() string {
+	if vm := l.loadVModule(); vm != nil {
+		return vm.spec
+	}
+	return ""
+}
+
+// loadVModule returns the current rules, nil if there are none
+// A named Logger without rules uses those of its nearest ancestor with rules
+func (l *Logger) loadVModule() *vmodule {
+	for c := l.core; c != nil; c = c.parent {
+		if vm, _ := c.vmodule.Load().(*vmodule); vm != nil {
+			return vm
+		}
+	}
+	return nil
+}
+
+// enabled reports whether a log call at the given level should be output, taking
+// vmodule rules into account
+// It must be called directly by log, logw or logContext so the caller can be found from callerSkip
+func (l *Logger) enabled(level Level) bool {
+	min := l.GetLevel()
+	vm := l.loadVModule()
+	if vm == nil {
+		return level >= min
+	}
+	// Most calls are above or below every rule and the Logger's level
+	if level >= min && level >= vm.max {
+		return true
+	}
+	if level < min && level < vm.min {
+		return false
+	}
+	l.mu.RLock()
+	prefix := l.prefix
+	callerSkip := l.callerSkip
+	l.mu.RUnlock()
+	if callerSkip == 0 {
+		callerSkip = 2
+	}
+	// Skip runtime.Callers and enabled itself
+	var pcs [1]uintptr
+	if runtime.Callers(callerSkip+2, pcs[:]) == 0 {
+		return level >= min
+	}
+	if d := vm.decide(pcs[0], prefix); d.matched {
+		return level >= d.level
+	}
+	return level >= min
+}
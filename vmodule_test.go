@@ -0,0 +1,170 @@
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestVModuleFile(t *testing.T) {
+	// Test that file rules override the Logger level for matching call sites only
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetFormatter(func(entry LogEntry) []byte { return []byte(entry.Message + "\n") })
+	logger.SetLevel(LevelInfo)
+
+	if err := logger.SetVModule("vmodule_test.go=trace"); err != nil {
+		t.Fatal("Expected no error from SetVModule, got:", err)
+	}
+	logger.Trace("trace here")
+	logger.Debugw("debug here")
+	if buffer.String() != "trace here\ndebug here\n" {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+	if logger.VModule() != "vmodule_test.go=trace" {
+		t.Fatal("Unexpected VModule:", logger.VModule())
+	}
+
+	// Rules for other files leave the Logger level in place
+	buffer.Reset()
+	_ = logger.SetVModule("other.go=trace")
+	logger.Debug("hidden")
+	if buffer.Len() != 0 {
+		t.Fatal("Expected Debug to stay disabled, got:", buffer.String())
+	}
+
+	// Rules can raise the minimum level too, and the first match wins
+	buffer.Reset()
+	if err := logger.SetVModule("vmodule_test=error,*_test.go=trace"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("hidden")
+	logger.Error("shown")
+	if buffer.String() != "shown\n" {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+
+	// An empty spec removes the rules
+	buffer.Reset()
+	_ = logger.SetVModule("")
+	logger.Debug("hidden")
+	logger.Warn("shown")
+	if buffer.String() != "shown\n" || logger.VModule() != "" {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+}
+
+func TestVModulePrefix(t *testing.T) {
+	// Test that rules match the Logger prefix, per derived Logger
+	buffer := &bytes.Buffer{}
+	logger := New(buffer)
+	logger.SetFormatter(func(entry LogEntry) []byte { return []byte(entry.Prefix + ":" + entry.Message + "\n") })
+	logger.SetLevel(LevelInfo)
+	if err := logger.SetVModule("db=debug,http/*=warn"); err != nil {
+		t.Fatal(err)
+	}
+
+	db, api, plain := logger.WithPrefix("db"), logger.WithPrefix("http/api"), logger.WithPrefix("cache")
+	for _, l := range []*Logger{db, api, plain} {
+		// Same call site with different prefixes
+		l.Debug("debug")
+		l.Info("info")
+	}
+	if buffer.String() != "db:debug\ndb:info\ncache:info\n" {
+		t.Fatal("Unexpected output:", buffer.String())
+	}
+}
+
+func TestVModulePrefixCacheBounded(t *testing.T) {
+	// Test that per-request prefixes do not grow the caches without limit
+	logger := New(&bytes.Buffer{})
+	logger.SetLevel(LevelInfo)
+	if err := logger.SetVModule("req-1*=debug"); err != nil {
+		t.Fatal(err)
+	}
+	enabled := 0
+	for i := 0; i < 2*maxCachedPrefixes; i++ {
+		if logger.WithPrefix(fmt.Sprintf("req-%d", i)).enabled(LevelDebug) {
+			enabled++
+		}
+	}
+	vm := logger.loadVModule()
+	files, prefixes := 0, 0
+	vm.files.Range(func(_, _ interface{}) bool { files++; return true })
+	vm.prefixes.Range(func(_, _ interface{}) bool { prefixes++; return true })
+	if files != 1 || prefixes != maxCachedPrefixes {
+		t.Fatalf("Expected 1 cached call site and %d prefixes, got %d and %d", maxCachedPrefixes, files, prefixes)
+	}
+	// Prefixes beyond the bound are still matched: req-1, req-10..19, req-100..199, req-1000..1999
+	if enabled != 1+10+100+1000 {
+		t.Fatal("Unexpected number of enabled prefixes:", enabled)
+	}
+	// Misses past the bound do not count further
+	for i := 0; i < 10; i++ {
+		vm.prefixRule(fmt.Sprintf("late-%d", i))
+	}
+	if n := atomic.LoadInt32(&vm.nprefixes); n != maxCachedPrefixes {
+		t.Fatal("Expected the counter to stop at the bound, got:", n)
+	}
+}
+
+func TestVModuleRuleMatch(t *testing.T) {
+	// Test matching patterns against caller files and package directories
+	tests := []struct {
+		pattern, file string
+		expected      bool
+	}{
+		{"db", "/src/app/db/conn.go", true},
+		{"db", "/src/app/db.go", true},
+		{"db", "/src/app/dbx/conn.go", false},
+		{"http/*", "/src/app/http/server.go", true},
+		{"http/*", "/src/app/http/mw/auth.go", true},
+		{"http/*", "/src/app/grpc/server.go", false},
+		{"app/http", "/src/app/http/server.go", true},
+		{"payments.go", "/src/app/billing/payments.go", true},
+		{"pay*", "/src/app/billing/payments.go", true},
+		{"payments.go", "/src/app/billing/refunds.go", false},
+	}
+	for _, tt := range tests {
+		if got := (vmoduleRule{pattern: tt.pattern}).matchFile(tt.file); got != tt.expected {
+			t.Errorf("%q matching %q = %v, expected %v", tt.pattern, tt.file, got, tt.expected)
+		}
+	}
+}
+
+func TestSetVModuleInvalid(t *testing.T) {
+	// Test rejecting malformed specs without changing the rules
+	logger := New(&bytes.Buffer{})
+	_ = logger.SetVModule("db=debug")
+	for _, spec := range []string{"db", "=debug", "db=verbose", "[=debug"} {
+		if err := logger.SetVModule(spec); err == nil {
+			t.Errorf("Expected SetVModule(%q) to fail", spec)
+		}
+	}
+	if logger.VModule() != "db=debug" {
+		t.Fatal("Expected the previous rules to be kept, got:", logger.VModule())
+	}
+}
+
+func TestGlobalVModule(t *testing.T) {
+	// Test that the package-level functions resolve rules against the caller's file
+	buffer := &bytes.Buffer{}
+
+	// Reset global logger
+	std = nil
+	stdOnce = sync.Once{}
+
+	SetOutput(buffer)
+	SetLevel(LevelWarn)
+	if err := SetVModule("vmodule_test.go=debug"); err != nil {
+		t.Fatal(err)
+	}
+	Debug("global debug")
+	Infow("global info")
+	if !strings.Contains(buffer.String(), "global debug") || !strings.Contains(buffer.String(), "global info") {
+		t.Fatal("Expected the rule to enable Debug, got:", buffer.String())
+	}
+}